@@ -0,0 +1,205 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+// V2SpecAnnotation holds the v2 spec of an object read through v1 when v1
+// cannot express it, for example when it has several destinations. Writing
+// the object back through v1 restores those fields, so v1 clients can edit
+// v2 objects without losing data.
+const V2SpecAnnotation = "configmapsync.apps.kapendra.com/v2-spec"
+
+// ConvertTo converts this ConfigMapSync (v1) to the Hub version (v2).
+func (src *ConfigMapSync) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*appsv2.ConfigMapSync)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	// Start from the preserved v2 spec, if any; the v1 fields below always
+	// win so that edits made through v1 take effect
+	dst.Spec = appsv2.ConfigMapSyncSpec{}
+	if preserved, ok := dst.Annotations[V2SpecAnnotation]; ok {
+		if err := json.Unmarshal([]byte(preserved), &dst.Spec); err != nil {
+			return fmt.Errorf("decoding %s annotation: %w", V2SpecAnnotation, err)
+		}
+		delete(dst.Annotations, V2SpecAnnotation)
+		if len(dst.Annotations) == 0 {
+			dst.Annotations = nil
+		}
+	}
+	convertSpecToV2(&src.Spec, &dst.Spec)
+
+	dst.Status = appsv2.ConfigMapSyncStatus{
+		ObservedGeneration: src.Status.ObservedGeneration,
+		Conditions:         copyConditions(src.Status.Conditions),
+		SyncStatus:         appsv2.SyncResult(src.Status.SyncStatus),
+		Message:            src.Status.Message,
+		LastSyncTime:       parseSyncTime(src.Status.LastSyncTime),
+		RetryCount:         src.Status.RetryCount,
+		Source: appsv2.SourceStatus{
+			Exists:          src.Status.SourceExists,
+			ResourceVersion: src.Status.SourceResourceVersion,
+			SyncedHash:      src.Status.SyncedSourceHash,
+		},
+		KeyCount:               src.Status.KeyCount,
+		ByteSize:               src.Status.ByteSize,
+		LastHandledReconcileAt: src.Status.LastHandledReconcileAt,
+		Pending:                (*appsv2.PendingChange)(src.Status.Pending.DeepCopy()),
+		RecentPropagations:     slices.Clone(src.Status.RecentPropagations),
+	}
+	for _, object := range src.Status.ManagedObjects {
+		dst.Status.ManagedObjects = append(dst.Status.ManagedObjects, appsv2.ManagedObject(object))
+	}
+	if src.Status.DestinationExists || src.Status.DestinationResourceVersion != "" || src.Status.LastChange != nil {
+		dst.Status.Destinations = []appsv2.DestinationStatus{{
+			Namespace:       src.Spec.DestinationNamespace,
+			Name:            src.Spec.ConfigMapName,
+			Exists:          src.Status.DestinationExists,
+			ResourceVersion: src.Status.DestinationResourceVersion,
+			LastChange:      (*appsv2.SyncChangeSummary)(src.Status.LastChange.DeepCopy()),
+		}}
+	}
+	return nil
+}
+
+// ConvertFrom converts the Hub version (v2) to this ConfigMapSync (v1).
+func (dst *ConfigMapSync) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*appsv2.ConfigMapSync)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	dst.Spec = ConfigMapSyncSpec{
+		SourceNamespace:       src.Spec.Source.Namespace,
+		ConfigMapName:         src.Spec.Source.Name,
+		SensitiveDataScanMode: SensitiveDataScanMode(src.Spec.SensitiveDataScanMode),
+	}
+	if len(src.Spec.Destinations) > 0 {
+		dst.Spec.DestinationNamespace = src.Spec.Destinations[0].Namespace
+	}
+	if verification := src.Spec.SignatureVerification; verification != nil {
+		dst.Spec.SignatureVerification = &SignatureVerification{PublicKeysRef: KeyReference(verification.PublicKeysRef)}
+	}
+	if decryption := src.Spec.Decryption; decryption != nil {
+		dst.Spec.Decryption = &Decryption{Provider: decryption.Provider, SecretRef: SecretReference(decryption.SecretRef)}
+	}
+
+	// Keep the full v2 spec when v1 cannot represent it
+	var restored appsv2.ConfigMapSyncSpec
+	convertSpecToV2(&dst.Spec, &restored)
+	if !equality.Semantic.DeepEqual(restored, src.Spec) {
+		preserved, err := json.Marshal(src.Spec)
+		if err != nil {
+			return fmt.Errorf("encoding %s annotation: %w", V2SpecAnnotation, err)
+		}
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[V2SpecAnnotation] = string(preserved)
+	}
+
+	dst.Status = ConfigMapSyncStatus{
+		ObservedGeneration:     src.Status.ObservedGeneration,
+		Conditions:             copyConditions(src.Status.Conditions),
+		SyncStatus:             string(src.Status.SyncStatus),
+		Message:                src.Status.Message,
+		RetryCount:             src.Status.RetryCount,
+		SourceExists:           src.Status.Source.Exists,
+		SourceResourceVersion:  src.Status.Source.ResourceVersion,
+		SyncedSourceHash:       src.Status.Source.SyncedHash,
+		KeyCount:               src.Status.KeyCount,
+		ByteSize:               src.Status.ByteSize,
+		LastHandledReconcileAt: src.Status.LastHandledReconcileAt,
+		Pending:                (*PendingChange)(src.Status.Pending.DeepCopy()),
+		RecentPropagations:     slices.Clone(src.Status.RecentPropagations),
+	}
+	for _, object := range src.Status.ManagedObjects {
+		dst.Status.ManagedObjects = append(dst.Status.ManagedObjects, ManagedObject(object))
+	}
+	if src.Status.LastSyncTime != nil {
+		dst.Status.LastSyncTime = src.Status.LastSyncTime.UTC().Format(time.RFC3339)
+	}
+	if len(src.Status.Destinations) > 0 {
+		destination := src.Status.Destinations[0]
+		dst.Status.DestinationExists = destination.Exists
+		dst.Status.DestinationResourceVersion = destination.ResourceVersion
+		dst.Status.LastChange = (*SyncChangeSummary)(destination.LastChange.DeepCopy())
+	}
+	return nil
+}
+
+// convertSpecToV2 applies the v1 spec fields to a v2 spec. The first
+// destination takes the v1 destination namespace; any further destinations
+// and a custom first destination name are left as they are.
+func convertSpecToV2(src *ConfigMapSyncSpec, dst *appsv2.ConfigMapSyncSpec) {
+	dst.Source = appsv2.SourceReference{
+		Namespace: src.SourceNamespace,
+		Name:      src.ConfigMapName,
+	}
+	if len(dst.Destinations) == 0 {
+		dst.Destinations = []appsv2.DestinationReference{{}}
+	}
+	dst.Destinations[0].Namespace = src.DestinationNamespace
+	dst.SensitiveDataScanMode = appsv2.SensitiveDataScanMode(src.SensitiveDataScanMode)
+
+	dst.SignatureVerification = nil
+	if src.SignatureVerification != nil {
+		dst.SignatureVerification = &appsv2.SignatureVerification{
+			PublicKeysRef: appsv2.KeyReference(src.SignatureVerification.PublicKeysRef),
+		}
+	}
+	dst.Decryption = nil
+	if src.Decryption != nil {
+		dst.Decryption = &appsv2.Decryption{
+			Provider:  src.Decryption.Provider,
+			SecretRef: appsv2.SecretReference(src.Decryption.SecretRef),
+		}
+	}
+}
+
+// parseSyncTime reads the RFC3339 v1 LastSyncTime; unparsable values are dropped.
+func parseSyncTime(value string) *metav1.Time {
+	if value == "" {
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	syncTime := metav1.NewTime(parsed)
+	return &syncTime
+}
+
+func copyConditions(conditions []metav1.Condition) []metav1.Condition {
+	if conditions == nil {
+		return nil
+	}
+	copied := make([]metav1.Condition, len(conditions))
+	for i := range conditions {
+		conditions[i].DeepCopyInto(&copied[i])
+	}
+	return copied
+}
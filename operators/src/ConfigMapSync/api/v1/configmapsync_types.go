@@ -35,8 +35,79 @@ type ConfigMapSyncSpec struct {
 	SourceNamespace      string `json:"sourceNamespace"`
 	DestinationNamespace string `json:"destinationNamespace"`
 	ConfigMapName        string `json:"configMapName"`
+
+	// SensitiveDataScanMode controls scanning of the source data for leaked
+	// credentials before it is propagated. Findings block the sync in Block
+	// mode and are only reported in Warn mode. Scanning is off when unset.
+	// +optional
+	SensitiveDataScanMode SensitiveDataScanMode `json:"sensitiveDataScanMode,omitempty"`
+
+	// SignatureVerification requires the source content to carry a valid
+	// detached signature from a trusted key before it is synced. Unsigned or
+	// badly signed content is not propagated and the destination keeps the
+	// last verified version.
+	// +optional
+	SignatureVerification *SignatureVerification `json:"signatureVerification,omitempty"`
+
+	// Decryption configures decryption of encrypted source values before
+	// they are written to the destination. Only the destination carries
+	// plaintext.
+	// +optional
+	Decryption *Decryption `json:"decryption,omitempty"`
+}
+
+// Decryption configures how encrypted source values are decrypted.
+type Decryption struct {
+	// Provider is the encryption format of the source values. Only SOPS
+	// with age recipients is supported.
+	// +kubebuilder:validation:Enum=sops
+	Provider string `json:"provider"`
+
+	// SecretRef names a Secret in the ConfigMapSync's namespace whose values
+	// hold age identities (AGE-SECRET-KEY-...).
+	SecretRef SecretReference `json:"secretRef"`
+}
+
+// SecretReference points at a Secret in the ConfigMapSync's namespace.
+type SecretReference struct {
+	// Name of the referenced Secret.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// SignatureVerification configures the keys trusted to sign source content.
+type SignatureVerification struct {
+	// PublicKeysRef names a Secret or ConfigMap in the ConfigMapSync's
+	// namespace. Every value in it is a PEM-encoded ed25519 or ECDSA public key.
+	PublicKeysRef KeyReference `json:"publicKeysRef"`
 }
 
+// KeyReference points at a Secret or ConfigMap in the ConfigMapSync's namespace.
+type KeyReference struct {
+	// Kind of the referenced object.
+	// +kubebuilder:validation:Enum=Secret;ConfigMap
+	// +kubebuilder:default=Secret
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Name of the referenced object.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// SensitiveDataScanMode selects how credential findings in the source are handled.
+// +kubebuilder:validation:Enum=Disabled;Warn;Block
+type SensitiveDataScanMode string
+
+const (
+	// SensitiveDataScanDisabled skips scanning entirely.
+	SensitiveDataScanDisabled SensitiveDataScanMode = "Disabled"
+	// SensitiveDataScanWarn reports findings but still propagates the data.
+	SensitiveDataScanWarn SensitiveDataScanMode = "Warn"
+	// SensitiveDataScanBlock reports findings and refuses to propagate the data.
+	SensitiveDataScanBlock SensitiveDataScanMode = "Block"
+)
+
 // ConfigMapSyncStatus defines the observed state of ConfigMapSync.
 type ConfigMapSyncStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
@@ -49,10 +120,119 @@ type ConfigMapSyncStatus struct {
 
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 	RetryCount int                `json:"retryCount,omitempty"` // Track retry attempts
+
+	// ObservedGeneration is the spec generation this status reflects.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// SyncedSourceHash is the hash of the source data last written to the
+	// destination.
+	// +optional
+	SyncedSourceHash string `json:"syncedSourceHash,omitempty"`
+
+	// SourceResourceVersion is the resourceVersion of the source ConfigMap
+	// that was last synced.
+	// +optional
+	SourceResourceVersion string `json:"sourceResourceVersion,omitempty"`
+
+	// DestinationResourceVersion is the resourceVersion of the destination
+	// ConfigMap after the last sync.
+	// +optional
+	DestinationResourceVersion string `json:"destinationResourceVersion,omitempty"`
+
+	// KeyCount is the number of keys in the destination after the last sync.
+	// +optional
+	KeyCount int `json:"keyCount,omitempty"`
+
+	// ByteSize is the size in bytes of the destination's keys and values
+	// after the last sync.
+	// +optional
+	ByteSize int64 `json:"byteSize,omitempty"`
+
+	// LastChange summarises the keys touched by the last sync that wrote
+	// the destination.
+	// +optional
+	LastChange *SyncChangeSummary `json:"lastChange,omitempty"`
+
+	// ManagedObjects lists every destination ConfigMap the controller has
+	// written and not yet cleaned up.
+	// +listType=map
+	// +listMapKey=namespace
+	// +listMapKey=name
+	// +optional
+	ManagedObjects []ManagedObject `json:"managedObjects,omitempty"`
+
+	// LastHandledReconcileAt is the last value of the requested-at
+	// annotation that the controller acted on.
+	// +optional
+	LastHandledReconcileAt string `json:"lastHandledReconcileAt,omitempty"`
+
+	// Pending reports a change that is held back instead of written.
+	// +optional
+	Pending *PendingChange `json:"pending,omitempty"`
+
+	// RecentPropagations are the times source changes were propagated in
+	// the past hour.
+	// +listType=atomic
+	// +optional
+	RecentPropagations []metav1.Time `json:"recentPropagations,omitempty"`
+}
+
+// PendingChange describes a change that has not been written yet.
+type PendingChange struct {
+	// Reason is why the change is held: Debouncing, PendingWindow or
+	// RateLimited.
+	Reason string `json:"reason"`
+
+	// Message explains what holds the change.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// SourceResourceVersion is the resourceVersion of the held source.
+	// +optional
+	SourceResourceVersion string `json:"sourceResourceVersion,omitempty"`
+
+	// NotBefore is the earliest time the change will be written.
+	// +optional
+	NotBefore *metav1.Time `json:"notBefore,omitempty"`
+}
+
+// ManagedObject identifies a destination ConfigMap written by the controller.
+type ManagedObject struct {
+	// Namespace of the ConfigMap.
+	Namespace string `json:"namespace"`
+
+	// Name of the ConfigMap.
+	Name string `json:"name"`
+}
+
+// SyncChangeSummary lists the destination keys added, removed and changed by
+// a sync. Values are never included.
+type SyncChangeSummary struct {
+	// Added keys that were not present in the destination.
+	// +optional
+	Added []string `json:"added,omitempty"`
+
+	// Removed keys that are no longer present in the source.
+	// +optional
+	Removed []string `json:"removed,omitempty"`
+
+	// Changed keys whose value was replaced.
+	// +optional
+	Changed []string `json:"changed,omitempty"`
+
+	// Truncated is set when a list was cut short to keep the status small.
+	// +optional
+	Truncated bool `json:"truncated,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Source",type=string,JSONPath=`.spec.sourceNamespace`
+// +kubebuilder:printcolumn:name="Destination",type=string,JSONPath=`.spec.destinationNamespace`
+// +kubebuilder:printcolumn:name="ConfigMap",type=string,JSONPath=`.spec.configMapName`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.syncStatus`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // ConfigMapSync is the Schema for the configmapsyncs API
 type ConfigMapSync struct {
@@ -0,0 +1,450 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConfigMapSyncSpec defines the desired state of ConfigMapSync
+type ConfigMapSyncSpec struct {
+	// Source is the ConfigMap whose data is propagated.
+	Source SourceReference `json:"source"`
+
+	// Destinations are the ConfigMaps kept in sync with the source.
+	// +kubebuilder:validation:MinItems=1
+	Destinations []DestinationReference `json:"destinations"`
+
+	// SensitiveDataScanMode controls scanning of the source data for leaked
+	// credentials before it is propagated. Findings block the sync in Block
+	// mode and are only reported in Warn mode. Scanning is off when unset.
+	// +optional
+	SensitiveDataScanMode SensitiveDataScanMode `json:"sensitiveDataScanMode,omitempty"`
+
+	// SensitiveDataScanAllowlist names source keys the scan skips, for known
+	// false positives. It lives on the ConfigMapSync rather than the source,
+	// so that writing the source does not allow exempting it from the scan.
+	// +optional
+	SensitiveDataScanAllowlist []string `json:"sensitiveDataScanAllowlist,omitempty"`
+
+	// SignatureVerification requires the source content to carry a valid
+	// detached signature from a trusted key before it is synced. Unsigned or
+	// badly signed content is not propagated and the destinations keep the
+	// last verified version.
+	// +optional
+	SignatureVerification *SignatureVerification `json:"signatureVerification,omitempty"`
+
+	// Decryption configures decryption of encrypted source values before
+	// they are written to the destinations. Only the destinations carry
+	// plaintext.
+	// +optional
+	Decryption *Decryption `json:"decryption,omitempty"`
+
+	// Force takes ownership of destination fields that another field manager
+	// owns, overwriting them with the source data. By default such a
+	// conflict is reported and the destination is left unchanged.
+	// +optional
+	Force bool `json:"force,omitempty"`
+
+	// DeletionPolicy decides what happens to a destination that is removed
+	// from the spec or whose ConfigMapSync is deleted. Delete, the default,
+	// deletes it; Orphan leaves it in place without the sync labels, so it
+	// is no longer managed.
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Suspend stops the controller from syncing this ConfigMapSync. The
+	// destinations keep their last synced content until it is resumed.
+	// Deleting a suspended ConfigMapSync still cleans up its destinations.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// SyncWindows restrict when changes are written to the destinations.
+	// Changes are held while a deny window is open, or while allow windows
+	// are given and none of them is open, and written once a window allows.
+	// +listType=atomic
+	// +optional
+	SyncWindows []SyncWindow `json:"syncWindows,omitempty"`
+
+	// Debounce is a quiet period that must pass after the last change to
+	// the source before the change is propagated, so that a burst of
+	// changes is written once.
+	// +optional
+	Debounce *metav1.Duration `json:"debounce,omitempty"`
+
+	// MaxRatePerHour caps how many source changes are propagated in any
+	// hour. Further changes are held until the oldest propagation of the
+	// past hour is an hour old. Unlimited when unset.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxRatePerHour int32 `json:"maxRatePerHour,omitempty"`
+
+	// ResyncInterval re-verifies the destinations at this interval even when
+	// no change was observed. It also sets how soon a missing source or a
+	// blocked sync is checked again, which is 5 minutes by default.
+	// +optional
+	ResyncInterval *metav1.Duration `json:"resyncInterval,omitempty"`
+
+	// RetryPolicy overrides how failed syncs are retried.
+	// +optional
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+}
+
+// RetryPolicy configures the retries of a failed sync.
+type RetryPolicy struct {
+	// BaseDelay is the delay before the first retry. It doubles with each
+	// consecutive failure. Defaults to the operator's --retry-base-delay.
+	// +optional
+	BaseDelay *metav1.Duration `json:"baseDelay,omitempty"`
+
+	// MaxDelay caps the delay between retries. Defaults to the operator's
+	// --retry-max-delay.
+	// +optional
+	MaxDelay *metav1.Duration `json:"maxDelay,omitempty"`
+
+	// MaxAttempts is the number of consecutive failed attempts after which
+	// the sync is Stalled with the last error and no longer retried, until
+	// its spec changes or a resync is requested. Unlimited when unset.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxAttempts int32 `json:"maxAttempts,omitempty"`
+}
+
+// SyncWindow is a recurring period in which changes are allowed or denied.
+type SyncWindow struct {
+	// Kind is Allow or Deny. Deny windows take precedence over allow windows.
+	// +kubebuilder:validation:Enum=Allow;Deny
+	Kind SyncWindowKind `json:"kind"`
+
+	// Schedule is a cron expression (minute hour day-of-month month
+	// day-of-week) for the times the window opens.
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// Duration is how long the window stays open, for example "10h".
+	Duration metav1.Duration `json:"duration"`
+
+	// TimeZone is the IANA time zone the schedule is read in, for example
+	// "Europe/Berlin". Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// SyncWindowKind selects whether a sync window allows or denies changes.
+type SyncWindowKind string
+
+const (
+	// SyncWindowAllow only lets changes through while the window is open.
+	SyncWindowAllow SyncWindowKind = "Allow"
+	// SyncWindowDeny holds changes while the window is open.
+	SyncWindowDeny SyncWindowKind = "Deny"
+)
+
+// SourceReference points at the source ConfigMap.
+type SourceReference struct {
+	// Namespace of the source ConfigMap.
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+
+	// Name of the source ConfigMap.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// DestinationReference points at a ConfigMap that receives the source data.
+type DestinationReference struct {
+	// Namespace of the destination ConfigMap.
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+
+	// Name of the destination ConfigMap. Defaults to the source name.
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+// Decryption configures how encrypted source values are decrypted.
+type Decryption struct {
+	// Provider is the encryption format of the source values. Only SOPS
+	// with age recipients is supported.
+	// +kubebuilder:validation:Enum=sops
+	Provider string `json:"provider"`
+
+	// SecretRef names a Secret in the ConfigMapSync's namespace whose values
+	// hold age identities (AGE-SECRET-KEY-...).
+	SecretRef SecretReference `json:"secretRef"`
+}
+
+// SecretReference points at a Secret in the ConfigMapSync's namespace.
+type SecretReference struct {
+	// Name of the referenced Secret.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// SignatureVerification configures the keys trusted to sign source content.
+type SignatureVerification struct {
+	// PublicKeysRef names a Secret or ConfigMap in the ConfigMapSync's
+	// namespace. Every value in it is a PEM-encoded ed25519 or ECDSA public key.
+	PublicKeysRef KeyReference `json:"publicKeysRef"`
+}
+
+// KeyReference points at a Secret or ConfigMap in the ConfigMapSync's namespace.
+type KeyReference struct {
+	// Kind of the referenced object.
+	// +kubebuilder:validation:Enum=Secret;ConfigMap
+	// +kubebuilder:default=Secret
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Name of the referenced object.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// SensitiveDataScanMode selects how credential findings in the source are handled.
+// +kubebuilder:validation:Enum=Disabled;Warn;Block
+type SensitiveDataScanMode string
+
+const (
+	// SensitiveDataScanDisabled skips scanning entirely.
+	SensitiveDataScanDisabled SensitiveDataScanMode = "Disabled"
+	// SensitiveDataScanWarn reports findings but still propagates the data.
+	SensitiveDataScanWarn SensitiveDataScanMode = "Warn"
+	// SensitiveDataScanBlock reports findings and refuses to propagate the data.
+	SensitiveDataScanBlock SensitiveDataScanMode = "Block"
+)
+
+// DeletionPolicy selects what happens to destinations that are no longer synced.
+// +kubebuilder:validation:Enum=Delete;Orphan
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes destinations that are no longer synced.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyOrphan keeps destinations that are no longer synced.
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
+// SyncResult is the outcome of the last sync.
+// +kubebuilder:validation:Enum=Success;Failed
+type SyncResult string
+
+const (
+	// SyncSucceeded means every destination holds the source data.
+	SyncSucceeded SyncResult = "Success"
+	// SyncFailed means the source could not be propagated.
+	SyncFailed SyncResult = "Failed"
+)
+
+// ConfigMapSyncStatus defines the observed state of ConfigMapSync.
+type ConfigMapSyncStatus struct {
+	// ObservedGeneration is the spec generation this status reflects.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions follow the kstatus conventions (Ready, Reconciling,
+	// Stalled) alongside the detailed sync conditions.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// SyncStatus is the outcome of the last sync.
+	// +optional
+	SyncStatus SyncResult `json:"syncStatus,omitempty"`
+
+	// Message describes the outcome of the last sync.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastHandledReconcileAt is the last value of the requested-at
+	// annotation that the controller acted on with a full resync.
+	// +optional
+	LastHandledReconcileAt string `json:"lastHandledReconcileAt,omitempty"`
+
+	// LastSyncTime is when a sync attempt last changed the status. Attempts
+	// that find everything up to date leave it alone.
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// RetryCount is the number of consecutive failed attempts. It is
+	// informational; retries are timed by the controller's workqueue.
+	// +optional
+	RetryCount int `json:"retryCount,omitempty"`
+
+	// Source reports the source ConfigMap as last observed.
+	// +optional
+	Source SourceStatus `json:"source,omitempty,omitzero"`
+
+	// Destinations reports each destination ConfigMap as last observed.
+	// +optional
+	Destinations []DestinationStatus `json:"destinations,omitempty"`
+
+	// ManagedObjects lists every destination ConfigMap the controller has
+	// written and not yet cleaned up, including ones since removed from the
+	// spec. Cleanup works from this list rather than the current spec.
+	// +listType=map
+	// +listMapKey=namespace
+	// +listMapKey=name
+	// +optional
+	ManagedObjects []ManagedObject `json:"managedObjects,omitempty"`
+
+	// Pending reports a change that is held back instead of written, and
+	// when it is expected to be written.
+	// +optional
+	Pending *PendingChange `json:"pending,omitempty"`
+
+	// RecentPropagations are the times source changes were propagated in
+	// the past hour. They are only kept while maxRatePerHour is set.
+	// +listType=atomic
+	// +optional
+	RecentPropagations []metav1.Time `json:"recentPropagations,omitempty"`
+
+	// KeyCount is the number of keys in the synced data.
+	// +optional
+	KeyCount int `json:"keyCount,omitempty"`
+
+	// ByteSize is the size in bytes of the synced keys and values.
+	// +optional
+	ByteSize int64 `json:"byteSize,omitempty"`
+}
+
+// SourceStatus reports the source ConfigMap.
+type SourceStatus struct {
+	// Exists is set when the source ConfigMap was found.
+	Exists bool `json:"exists"`
+
+	// ResourceVersion of the source ConfigMap that was last synced.
+	// +optional
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+
+	// SyncedHash is the versioned hash of the content last written to the
+	// destinations, as recorded in their source-hash annotation.
+	// +optional
+	SyncedHash string `json:"syncedHash,omitempty"`
+}
+
+// PendingChange describes a change that has not been written yet.
+type PendingChange struct {
+	// Reason is why the change is held: Debouncing, PendingWindow or
+	// RateLimited.
+	Reason string `json:"reason"`
+
+	// Message explains what holds the change.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// SourceResourceVersion is the resourceVersion of the held source.
+	// +optional
+	SourceResourceVersion string `json:"sourceResourceVersion,omitempty"`
+
+	// NotBefore is the earliest time the change will be written. It is
+	// unset when that is not known, for example during a freeze without end.
+	// +optional
+	NotBefore *metav1.Time `json:"notBefore,omitempty"`
+}
+
+// ManagedObject identifies a destination ConfigMap written by the controller.
+type ManagedObject struct {
+	// Namespace of the ConfigMap.
+	Namespace string `json:"namespace"`
+
+	// Name of the ConfigMap.
+	Name string `json:"name"`
+}
+
+// DestinationStatus reports one destination ConfigMap.
+type DestinationStatus struct {
+	// Namespace of the destination ConfigMap.
+	Namespace string `json:"namespace"`
+
+	// Name of the destination ConfigMap.
+	Name string `json:"name"`
+
+	// Exists is set when the destination ConfigMap is present.
+	Exists bool `json:"exists"`
+
+	// ResourceVersion of the destination ConfigMap after the last sync.
+	// +optional
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+
+	// LastChange summarises the keys touched by the last sync that wrote
+	// this destination.
+	// +optional
+	LastChange *SyncChangeSummary `json:"lastChange,omitempty"`
+}
+
+// SyncChangeSummary lists the destination keys added, removed and changed by
+// a sync. Values are never included.
+type SyncChangeSummary struct {
+	// Added keys that were not present in the destination.
+	// +optional
+	Added []string `json:"added,omitempty"`
+
+	// Removed keys that are no longer present in the source.
+	// +optional
+	Removed []string `json:"removed,omitempty"`
+
+	// Changed keys whose value was replaced.
+	// +optional
+	Changed []string `json:"changed,omitempty"`
+
+	// Truncated is set when a list was cut short to keep the status small.
+	// +optional
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Source",type=string,JSONPath=`.spec.source.namespace`
+// +kubebuilder:printcolumn:name="Destinations",type=string,JSONPath=`.spec.destinations[*].namespace`
+// +kubebuilder:printcolumn:name="ConfigMap",type=string,JSONPath=`.spec.source.name`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.syncStatus`
+// +kubebuilder:printcolumn:name="Suspended",type=boolean,JSONPath=`.spec.suspend`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ConfigMapSync is the Schema for the configmapsyncs API
+type ConfigMapSync struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of ConfigMapSync
+	// +required
+	Spec ConfigMapSyncSpec `json:"spec"`
+
+	// status defines the observed state of ConfigMapSync
+	// +optional
+	Status ConfigMapSyncStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// ConfigMapSyncList contains a list of ConfigMapSync
+type ConfigMapSyncList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConfigMapSync `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConfigMapSync{}, &ConfigMapSyncList{})
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SyncFreezeSpec defines a period in which no ConfigMapSync changes are written.
+type SyncFreezeSpec struct {
+	// Start is when the freeze begins. The freeze is in effect right away
+	// when unset.
+	// +optional
+	Start *metav1.Time `json:"start,omitempty"`
+
+	// End is when the freeze is lifted. The freeze lasts until the
+	// SyncFreeze is deleted when unset.
+	// +optional
+	End *metav1.Time `json:"end,omitempty"`
+
+	// Reason is reported in the status of the ConfigMapSyncs it holds.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Selector limits the freeze to ConfigMapSyncs with matching labels.
+	// Every ConfigMapSync is frozen when unset.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Start",type=date,JSONPath=`.spec.start`
+// +kubebuilder:printcolumn:name="End",type=date,JSONPath=`.spec.end`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.spec.reason`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// SyncFreeze is the Schema for the syncfreezes API. While a SyncFreeze is in
+// effect, changes to the ConfigMapSyncs it selects are held.
+type SyncFreeze struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the freeze period and the ConfigMapSyncs it applies to
+	// +required
+	Spec SyncFreezeSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// SyncFreezeList contains a list of SyncFreeze
+type SyncFreezeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SyncFreeze `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SyncFreeze{}, &SyncFreezeList{})
+}
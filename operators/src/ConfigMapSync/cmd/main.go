@@ -17,28 +17,46 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+	// Sync window time zones must resolve in minimal images without tzdata
+	_ "time/tzdata"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	appsv1 "operators/src/ConfigMapSync/api/v1"
+	appsv2 "operators/src/ConfigMapSync/api/v2"
 	"operators/src/ConfigMapSync/internal/controller"
+	webhookappsv2 "operators/src/ConfigMapSync/internal/webhook/v2"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -51,6 +69,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(appsv1.AddToScheme(scheme))
+	utilruntime.Must(appsv2.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -62,8 +81,22 @@ func main() {
 	var enableLeaderElection bool
 	var probeAddr string
 	var secureMetrics bool
+	var perSyncMetrics bool
 	var enableHTTP2 bool
 	var tlsOpts []func(*tls.Config)
+	var quotaMaxDestinations int64
+	var quotaMaxBytes, quotaMaxObjectSize string
+	var tracingEndpoint string
+	var tracingInsecure bool
+	var tracingSampleRatio float64
+	var retryBaseDelay, retryMaxDelay time.Duration
+	var shards int
+	var shardIdentity, shardLeaseNamespace string
+	var shardLeaseDuration time.Duration
+	var watchNamespaces, sourceNamespaces string
+	var orphanPolicy string
+	var orphanSweepInterval time.Duration
+	var syncFreezes bool
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -72,6 +105,9 @@ func main() {
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.BoolVar(&secureMetrics, "metrics-secure", true,
 		"If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
+	flag.BoolVar(&perSyncMetrics, "metrics-per-sync-labels", true,
+		"If set, export gauges labelled with each ConfigMapSync's name and namespace. "+
+			"Disable on large clusters to bound metric cardinality.")
 	flag.StringVar(&webhookCertPath, "webhook-cert-path", "", "The directory that contains the webhook certificate.")
 	flag.StringVar(&webhookCertName, "webhook-cert-name", "tls.crt", "The name of the webhook certificate file.")
 	flag.StringVar(&webhookCertKey, "webhook-cert-key", "tls.key", "The name of the webhook key file.")
@@ -81,6 +117,46 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.Int64Var(&quotaMaxDestinations, "quota-max-destinations-per-sync", 0,
+		"Maximum number of destinations a single ConfigMapSync may manage. 0 disables the limit.")
+	flag.StringVar(&quotaMaxBytes, "quota-max-bytes-per-source-namespace", "0",
+		"Maximum total size of ConfigMaps synced from one source namespace, e.g. 10Mi. 0 disables the limit.")
+	flag.StringVar(&quotaMaxObjectSize, "quota-max-object-size", "0",
+		"Maximum size of a single synced ConfigMap, e.g. 256Ki. 0 disables the limit.")
+	flag.StringVar(&tracingEndpoint, "tracing-endpoint", "",
+		"The host:port of an OTLP gRPC collector to export reconcile traces to. Leave empty to disable tracing.")
+	flag.BoolVar(&tracingInsecure, "tracing-insecure", false,
+		"If set, traces are exported to the collector without TLS.")
+	flag.Float64Var(&tracingSampleRatio, "tracing-sample-ratio", 1.0,
+		"Fraction of reconciles to trace, between 0 and 1.")
+	flag.DurationVar(&retryBaseDelay, "retry-base-delay", controller.DefaultRetryBaseDelay,
+		"Delay before the first retry of a failed sync. It doubles with each consecutive failure.")
+	flag.DurationVar(&retryMaxDelay, "retry-max-delay", controller.DefaultRetryMaxDelay,
+		"Upper bound of the delay between retries of a failed sync.")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
+		"Comma-separated namespaces whose ConfigMapSyncs are reconciled and that destinations may be written to. "+
+			"Empty watches all namespaces.")
+	flag.StringVar(&sourceNamespaces, "source-namespaces", "",
+		"Comma-separated namespaces that sources may additionally be read from. Requires --watch-namespaces; "+
+			"syncs reading from namespaces in neither list are rejected.")
+	flag.StringVar(&orphanPolicy, "orphan-policy", string(controller.OrphanPolicyReport),
+		"What to do with destination ConfigMaps whose ConfigMapSync is gone or no longer targets them: "+
+			"delete, report (Warning event on the ConfigMap), dry-run (log only) or off.")
+	flag.DurationVar(&orphanSweepInterval, "orphan-sweep-interval", controller.DefaultOrphanSweepInterval,
+		"How often to sweep for orphaned destination ConfigMaps.")
+	flag.BoolVar(&syncFreezes, "sync-freezes", true,
+		"Hold changes during cluster-wide SyncFreezes. Needs cluster-wide read access to syncfreezes, "+
+			"so it defaults to false when --watch-namespaces is set.")
+	flag.IntVar(&shards, "shards", 0,
+		"Split ConfigMapSyncs into this many shards, by namespace, and spread them over all replicas "+
+			"using Leases. Replaces leader election. 0 or 1 disables sharding.")
+	flag.StringVar(&shardIdentity, "shard-identity", "",
+		"Unique name of this replica in the shard leases. Defaults to the hostname.")
+	flag.StringVar(&shardLeaseNamespace, "shard-lease-namespace", "",
+		"Namespace of the shard leases. Defaults to the namespace the operator runs in.")
+	flag.DurationVar(&shardLeaseDuration, "shard-lease-duration", controller.DefaultShardLeaseDuration,
+		"How long a replica's shards stay assigned to it without renewal. "+
+			"A failed replica's shards are taken over within this time plus a third of it.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -89,6 +165,57 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	maxBytesPerSourceNamespace, err := resource.ParseQuantity(quotaMaxBytes)
+	if err != nil {
+		setupLog.Error(err, "invalid --quota-max-bytes-per-source-namespace")
+		os.Exit(1)
+	}
+	maxObjectSize, err := resource.ParseQuantity(quotaMaxObjectSize)
+	if err != nil {
+		setupLog.Error(err, "invalid --quota-max-object-size")
+		os.Exit(1)
+	}
+	quotas := controller.QuotaLimits{
+		MaxDestinationsPerSync:     quotaMaxDestinations,
+		MaxBytesPerSourceNamespace: maxBytesPerSourceNamespace.Value(),
+		MaxObjectSize:              maxObjectSize.Value(),
+	}
+
+	orphans, err := controller.ParseOrphanPolicy(orphanPolicy)
+	if err != nil {
+		setupLog.Error(err, "invalid --orphan-policy")
+		os.Exit(1)
+	}
+
+	namespaces := controller.NamespaceScope{
+		Watch:   controller.ParseNamespaces(watchNamespaces),
+		Sources: controller.ParseNamespaces(sourceNamespaces),
+	}
+	if err := namespaces.Validate(); err != nil {
+		setupLog.Error(err, "invalid --source-namespaces, set --watch-namespaces too")
+		os.Exit(1)
+	}
+	// Secrets are only read on demand (for example trusted signing keys),
+	// so they are fetched directly instead of caching every Secret in the
+	// cluster. A namespace-scoped operator may only get its own namespaces,
+	// so those are not cached either.
+	uncached := []client.Object{&corev1.Secret{}}
+	if namespaces.Restricted() {
+		uncached = append(uncached, &corev1.Namespace{})
+		setupLog.Info("Restricting the operator to namespaces", "watch", namespaces.Watch, "sources", namespaces.Sources)
+		// SyncFreezes are cluster-scoped, which a namespace-restricted
+		// operator usually cannot read
+		if !flagSet("sync-freezes") {
+			syncFreezes = false
+		}
+	}
+
+	sharding := shards > 1
+	if sharding && enableLeaderElection {
+		setupLog.Info("Sharding is enabled, disabling leader election so that every replica does work")
+		enableLeaderElection = false
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -185,6 +312,18 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "3fe4c69f.kapendra.com",
+		// Only destination ConfigMaps are cached in full; the controller
+		// keeps its own cache of sources and watches other ConfigMaps as
+		// metadata, so large unrelated ConfigMaps are never held in memory.
+		Cache: cache.Options{
+			DefaultNamespaces: namespaces.CacheNamespaces(),
+			ByObject:          controller.CacheByObject(),
+		},
+		Client: client.Options{
+			Cache: &client.CacheOptions{
+				DisableFor: uncached,
+			},
+		},
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -202,13 +341,70 @@ func main() {
 		os.Exit(1)
 	}
 
+	if tracingEndpoint != "" {
+		tracerProvider, err := setupTracing(context.Background(), tracingEndpoint, tracingInsecure, tracingSampleRatio)
+		if err != nil {
+			setupLog.Error(err, "unable to set up tracing")
+			os.Exit(1)
+		}
+		// Flush buffered spans once the manager is shutting down
+		if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return tracerProvider.Shutdown(context.Background())
+		})); err != nil {
+			setupLog.Error(err, "unable to add tracer provider to manager")
+			os.Exit(1)
+		}
+		setupLog.Info("Exporting traces", "endpoint", tracingEndpoint, "sampleRatio", tracingSampleRatio)
+	}
+
+	var shardManager *controller.ShardManager
+	if sharding {
+		shardOptions, err := shardingOptions(shards, shardIdentity, shardLeaseNamespace, shardLeaseDuration)
+		if err != nil {
+			setupLog.Error(err, "unable to configure sharding")
+			os.Exit(1)
+		}
+		shardManager = controller.NewShardManager(mgr.GetClient(), mgr.GetAPIReader(), shardOptions)
+		setupLog.Info("Sharding ConfigMapSyncs", "shards", shards, "identity", shardOptions.Identity,
+			"leaseNamespace", shardOptions.Namespace)
+	}
+
 	if err := (&controller.ConfigMapSyncReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+		Recorder:       mgr.GetEventRecorderFor("configmapsync-controller"),
+		APIReader:      mgr.GetAPIReader(),
+		Quotas:         quotas,
+		Retry:          controller.RetryOptions{BaseDelay: retryBaseDelay, MaxDelay: retryMaxDelay},
+		PerSyncMetrics: perSyncMetrics,
+		Namespaces:     namespaces,
+		Shards:         shardManager,
+		SyncFreezes:    syncFreezes,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ConfigMapSync")
 		os.Exit(1)
 	}
+	if orphans != controller.OrphanPolicyOff {
+		if err := mgr.Add(&controller.OrphanCollector{
+			Client:    mgr.GetClient(),
+			APIReader: mgr.GetAPIReader(),
+			Recorder:  mgr.GetEventRecorderFor("configmapsync-orphan-collector"),
+			Policy:    orphans,
+			Interval:  orphanSweepInterval,
+			Shards:    shardManager,
+		}); err != nil {
+			setupLog.Error(err, "unable to add orphan collector to manager")
+			os.Exit(1)
+		}
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err := webhookappsv2.SetupConfigMapSyncWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ConfigMapSync")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if metricsCertWatcher != nil {
@@ -242,3 +438,63 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// setupTracing installs a global tracer provider that batches spans to an
+// OTLP gRPC collector. Sampling follows the parent span when there is one.
+func setupTracing(ctx context.Context, endpoint string, insecure bool, sampleRatio float64) (*sdktrace.TracerProvider, error) {
+	exporterOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint)}
+	if insecure {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(sdkresource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName("configmapsync-controller"),
+		)),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	return tracerProvider, nil
+}
+
+// shardingOptions fills in the replica identity and the lease namespace
+// when they are not set, the way leader election does.
+func shardingOptions(shards int, identity, namespace string, leaseDuration time.Duration) (controller.ShardOptions, error) {
+	if identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return controller.ShardOptions{}, fmt.Errorf("determining replica identity: %w", err)
+		}
+		identity = hostname
+	}
+	if namespace == "" {
+		data, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+		if err != nil {
+			return controller.ShardOptions{}, fmt.Errorf("determining lease namespace, set --shard-lease-namespace: %w", err)
+		}
+		namespace = strings.TrimSpace(string(data))
+	}
+	return controller.ShardOptions{
+		Shards:        shards,
+		Identity:      identity,
+		Namespace:     namespace,
+		LeaseDuration: leaseDuration,
+	}, nil
+}
+
+// flagSet reports whether the flag name was passed on the command line.
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
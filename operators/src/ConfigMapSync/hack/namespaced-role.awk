@@ -0,0 +1,17 @@
+# Derives the Role of config/rbac-namespaced from the manager-role
+# ClusterRole. Rules for cluster-scoped resources are dropped, since a Role
+# cannot grant them.
+function flush() {
+	if (rule != "" && !cluster) printf "%s", rule
+	rule = ""
+	cluster = 0
+}
+/^kind: ClusterRole$/ { print "kind: Role"; next }
+/^- apiGroups:/ { flush() }
+/^- apiGroups:/, 0 {
+	rule = rule $0 "\n"
+	if ($0 ~ /^  - (namespaces|syncfreezes)$/) cluster = 1
+	next
+}
+{ print }
+END { flush() }
@@ -0,0 +1,184 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+// sourceIndexField indexes ConfigMapSyncs by the namespace/name of their source.
+const sourceIndexField = ".spec.source"
+
+// CacheByObject returns the manager cache settings for the objects the
+// controller reads. Only destination ConfigMaps, which carry the managed-by
+// label, are cached with their content; sources are read through the
+// source cache and every other ConfigMap is only watched as metadata.
+func CacheByObject() map[client.Object]cache.ByObject {
+	return map[client.Object]cache.ByObject{
+		&corev1.ConfigMap{}: {
+			Label: labels.SelectorFromSet(labels.Set{LabelManagedBy: ManagedByValue}),
+		},
+	}
+}
+
+// configMapMetadata returns an empty metadata-only ConfigMap.
+func configMapMetadata() *metav1.PartialObjectMetadata {
+	configMap := &metav1.PartialObjectMetadata{}
+	configMap.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+	return configMap
+}
+
+// sourceCache keeps the full content of the ConfigMaps that are referenced
+// as sources. Whether a cached copy is current is decided against the
+// resourceVersion in a metadata-only cache of all ConfigMaps, so a source
+// is only read from the API server when it changed or was not read before.
+// Sources that no ConfigMapSync references any more are dropped.
+type sourceCache struct {
+	metadata client.Reader
+	reader   client.Reader
+
+	mu      sync.Mutex
+	entries map[types.NamespacedName]*corev1.ConfigMap
+	// sources maps each ConfigMapSync to the source it last read.
+	sources map[types.NamespacedName]types.NamespacedName
+}
+
+// newSourceCache returns a sourceCache that checks freshness with metadata
+// and reads changed sources with reader, which should bypass the cache.
+func newSourceCache(metadata, reader client.Reader) *sourceCache {
+	return &sourceCache{
+		metadata: metadata,
+		reader:   reader,
+		entries:  map[types.NamespacedName]*corev1.ConfigMap{},
+		sources:  map[types.NamespacedName]types.NamespacedName{},
+	}
+}
+
+// get reads the source key of the ConfigMapSync syncKey into configMap.
+func (c *sourceCache) get(ctx context.Context, syncKey, key types.NamespacedName, configMap *corev1.ConfigMap) error {
+	c.track(syncKey, key)
+
+	current := configMapMetadata()
+	if err := c.metadata.Get(ctx, key, current); err == nil {
+		c.mu.Lock()
+		cached := c.entries[key]
+		c.mu.Unlock()
+		if cached != nil && cached.ResourceVersion == current.ResourceVersion {
+			cached.DeepCopyInto(configMap)
+			return nil
+		}
+	}
+
+	// Changed, not read before or not yet in the metadata cache
+	if err := c.reader.Get(ctx, key, configMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			c.mu.Lock()
+			delete(c.entries, key)
+			c.mu.Unlock()
+		}
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.referenced(key) {
+		c.entries[key] = configMap.DeepCopy()
+	}
+	return nil
+}
+
+// invalidate drops the cached copy of the source key, so the next get reads
+// it from the API server.
+func (c *sourceCache) invalidate(key types.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// forget drops the ConfigMapSync syncKey, and its source unless another
+// ConfigMapSync reads it too.
+func (c *sourceCache) forget(syncKey types.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	source, ok := c.sources[syncKey]
+	if !ok {
+		return
+	}
+	delete(c.sources, syncKey)
+	if !c.referenced(source) {
+		delete(c.entries, source)
+	}
+}
+
+// track records that syncKey reads key, dropping its previous source when
+// nothing else references it.
+func (c *sourceCache) track(syncKey, key types.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	previous, ok := c.sources[syncKey]
+	c.sources[syncKey] = key
+	if ok && previous != key && !c.referenced(previous) {
+		delete(c.entries, previous)
+	}
+}
+
+// referenced reports whether any ConfigMapSync reads key. c.mu must be held.
+func (c *sourceCache) referenced(key types.NamespacedName) bool {
+	for _, source := range c.sources {
+		if source == key {
+			return true
+		}
+	}
+	return false
+}
+
+// getSource reads the source ConfigMap of configMapSync, through the source
+// cache when the controller set one up.
+func (r *ConfigMapSyncReconciler) getSource(ctx context.Context, configMapSync *appsv2.ConfigMapSync, configMap *corev1.ConfigMap) error {
+	if r.sources == nil {
+		return r.Get(ctx, sourceKey(configMapSync), configMap)
+	}
+	return r.sources.get(ctx, client.ObjectKeyFromObject(configMapSync), sourceKey(configMapSync), configMap)
+}
+
+// syncsForSource maps a changed ConfigMap to the ConfigMapSyncs that read
+// it as their source.
+func (r *ConfigMapSyncReconciler) syncsForSource(ctx context.Context, configMap *metav1.PartialObjectMetadata) []reconcile.Request {
+	syncs := &appsv2.ConfigMapSyncList{}
+	key := client.ObjectKeyFromObject(configMap).String()
+	if err := r.List(ctx, syncs, client.MatchingFields{sourceIndexField: key}); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list ConfigMapSyncs of changed source", "source", key)
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(syncs.Items))
+	for i := range syncs.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&syncs.Items[i])})
+	}
+	return requests
+}
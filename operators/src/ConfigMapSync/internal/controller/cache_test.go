@@ -0,0 +1,172 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("Source cache", func() {
+	ctx := context.Background()
+	syncKey := types.NamespacedName{Name: "app-sync", Namespace: "team-a"}
+	key := types.NamespacedName{Name: "app-config", Namespace: "team-a"}
+
+	var (
+		c       client.Client
+		reads   int
+		sources *sourceCache
+	)
+
+	BeforeEach(func() {
+		c = fake.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Data:       map[string]string{"key": "value"},
+		}).Build()
+		reads = 0
+		apiReader := interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				reads++
+				return c.Get(ctx, key, obj, opts...)
+			},
+		})
+		sources = newSourceCache(c, apiReader)
+	})
+
+	get := func() *corev1.ConfigMap {
+		configMap := &corev1.ConfigMap{}
+		Expect(sources.get(ctx, syncKey, key, configMap)).To(Succeed())
+		return configMap
+	}
+
+	It("should only read a source from the API server when it changed", func() {
+		Expect(get().Data).To(HaveKeyWithValue("key", "value"))
+		Expect(get().Data).To(HaveKeyWithValue("key", "value"))
+		Expect(reads).To(Equal(1))
+
+		changed := get()
+		changed.Data["key"] = "changed"
+		Expect(c.Update(ctx, changed)).To(Succeed())
+		Expect(get().Data).To(HaveKeyWithValue("key", "changed"))
+		Expect(reads).To(Equal(2))
+	})
+
+	It("should hand out copies of the cached source", func() {
+		get().Data["key"] = "mutated"
+		Expect(get().Data).To(HaveKeyWithValue("key", "value"))
+	})
+
+	It("should drop sources that are no longer referenced", func() {
+		get()
+		Expect(sources.entries).To(HaveKey(key))
+
+		other := types.NamespacedName{Name: "other-sync", Namespace: "team-a"}
+		Expect(sources.get(ctx, other, key, &corev1.ConfigMap{})).To(Succeed())
+		sources.forget(syncKey)
+		Expect(sources.entries).To(HaveKey(key), "another ConfigMapSync still reads it")
+
+		err := sources.get(ctx, other, types.NamespacedName{Name: "missing", Namespace: "team-a"}, &corev1.ConfigMap{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		Expect(sources.entries).To(BeEmpty())
+	})
+})
+
+// BenchmarkConfigMapCacheMemory compares the heap held by an informer store
+// of every ConfigMap in a cluster with the layout the controller uses: full
+// content for the synced destinations and referenced sources, metadata for
+// everything else. Run it with
+//
+//	go test ./internal/controller -run '^$' -bench ConfigMapCacheMemory
+func BenchmarkConfigMapCacheMemory(b *testing.B) {
+	const (
+		configMaps = 2000
+		// Every tenth ConfigMap is a destination or a source
+		syncedEvery = 10
+		valueSize   = 64 << 10
+	)
+	newConfigMap := func(i int) *corev1.ConfigMap {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            fmt.Sprintf("config-%d", i),
+				Namespace:       fmt.Sprintf("namespace-%d", i%50),
+				ResourceVersion: fmt.Sprint(i),
+				Labels:          map[string]string{"app": "example"},
+			},
+			Data: map[string]string{"config.yaml": strings.Repeat("x", valueSize)},
+		}
+		if i%syncedEvery == 0 {
+			configMap.Labels[LabelManagedBy] = ManagedByValue
+		}
+		return configMap
+	}
+	layouts := map[string]func(*corev1.ConfigMap) any{
+		"all-full": func(configMap *corev1.ConfigMap) any {
+			return configMap
+		},
+		"selective": func(configMap *corev1.ConfigMap) any {
+			if configMap.Labels[LabelManagedBy] == ManagedByValue {
+				return configMap
+			}
+			metadata := configMapMetadata()
+			configMap.ObjectMeta.DeepCopyInto(&metadata.ObjectMeta)
+			metadata.ManagedFields = nil
+			return metadata
+		},
+	}
+
+	for _, name := range []string{"all-full", "selective"} {
+		layout := layouts[name]
+		b.Run(name, func(b *testing.B) {
+			var held uint64
+			for range b.N {
+				before := heapInUse()
+				store := toolscache.NewStore(toolscache.MetaNamespaceKeyFunc)
+				for i := range configMaps {
+					if err := store.Add(layout(newConfigMap(i))); err != nil {
+						b.Fatal(err)
+					}
+				}
+				held += heapInUse() - before
+				runtime.KeepAlive(store)
+			}
+			b.ReportMetric(float64(held)/float64(b.N)/(1<<20), "MiB/cache")
+		})
+	}
+}
+
+// heapInUse returns the live heap after a garbage collection.
+func heapInUse() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
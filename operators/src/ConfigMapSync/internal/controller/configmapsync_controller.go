@@ -18,22 +18,32 @@ package controller
 
 import (
 	"context"
-	"crypto/sha256"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
-	appsv1 "operators/src/ConfigMapSync/api/v1"
+	appsv2 "operators/src/ConfigMapSync/api/v2"
 
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
@@ -41,70 +51,170 @@ const (
 	TypeSynced             = "Synced"
 	TypeSourceAvailable    = "SourceAvailable"
 	TypeReady              = "Ready"
+	// TypeReconciling and TypeStalled follow the kstatus abnormal-true
+	// convention: they are only present while the sync is in progress or
+	// cannot make progress without intervention.
+	TypeReconciling = "Reconciling"
+	TypeStalled     = "Stalled"
+	// TypeSensitiveDataDetected is set when the source scan finds credentials.
+	// Its message names the offending keys but never their values.
+	TypeSensitiveDataDetected = "SensitiveDataDetected"
+	// TypeSignatureVerified reports whether the source carried a valid
+	// signature from one of the trusted keys.
+	TypeSignatureVerified = "SignatureVerified"
+	// TypeDecrypted reports whether encrypted source values could be decrypted.
+	TypeDecrypted = "Decrypted"
+	// TypeQuotaExceeded is set when writing the destination would exceed a quota.
+	TypeQuotaExceeded = "QuotaExceeded"
+	// TypePermissionsSufficient reports whether the operator's RBAC covers
+	// every verb the sync needs in the source and destination namespaces.
+	TypePermissionsSufficient = "PermissionsSufficient"
+)
+
+// Labels applied to every destination ConfigMap to track the sync relationship.
+const (
+	LabelSyncName      = "configmapsync.apps.kapendra.com/sync-name"
+	LabelSyncNamespace = "configmapsync.apps.kapendra.com/sync-namespace"
+	LabelManagedBy     = "configmapsync.apps.kapendra.com/managed-by"
+	ManagedByValue     = "configmapsync-controller"
 )
 
 // ConfigMapSyncReconciler reconciles a ConfigMapSync object
 type ConfigMapSyncReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// Recorder emits Kubernetes Events about the sync lifecycle.
+	Recorder record.EventRecorder
+
+	// APIReader reads ConfigMaps the manager cache does not hold, such as
+	// trusted keys, which lack the managed-by label, and re-reads the
+	// ConfigMapSync when its status patch conflicts. When nil these are read
+	// through the client.
+	APIReader client.Reader
+
+	// Quotas are the cluster-wide fan-out limits. Namespaces can override
+	// them with annotations.
+	Quotas QuotaLimits
+
+	// Tracer creates the reconcile spans. When nil the global tracer
+	// provider is used, which is a no-op unless tracing is enabled.
+	Tracer trace.Tracer
+
+	// Retry sets the backoff between attempts after a failed reconcile.
+	Retry RetryOptions
+
+	// PerSyncMetrics enables the gauges labelled with each ConfigMapSync's
+	// name and namespace. Disable it on large clusters to bound cardinality.
+	PerSyncMetrics bool
+
+	// Namespaces restricts the namespaces a sync may involve. ConfigMapSyncs
+	// reaching outside it are reported as Stalled.
+	Namespaces NamespaceScope
+
+	// Shards splits the ConfigMapSyncs between replicas. When nil every
+	// ConfigMapSync is reconciled, which relies on leader election to keep
+	// a single replica active.
+	Shards *ShardManager
+
+	// SyncFreezes enables the cluster-wide SyncFreeze resources, which
+	// needs cluster-wide read access to them.
+	SyncFreezes bool
+
+	// now returns the current time for sync windows; time.Now when nil.
+	now func() time.Time
+
+	// permissions caches access reviews for the RBAC preflight. It is set up
+	// by SetupWithManager; when nil the preflight is skipped.
+	permissions *permissionCache
+
+	// sources caches the content of source ConfigMaps. It is set up by
+	// SetupWithManager; when nil sources are read through the client.
+	sources *sourceCache
+
+	// backoff is the per-item retry backoff of the workqueue, which applies
+	// each sync's retry policy. It is set up by SetupWithManager.
+	backoff *jitteredExponentialRateLimiter
 }
 
 // +kubebuilder:rbac:groups=apps.kapendra.com,resources=configmapsyncs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps.kapendra.com,resources=configmapsyncs/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=apps.kapendra.com,resources=configmapsyncs/finalizers,verbs=update
+// +kubebuilder:rbac:groups=apps.kapendra.com,resources=syncfreezes,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile copies the source ConfigMap of a ConfigMapSync to each of its
+// destinations, one way. A deleted sync cleans up its destinations and a
+// suspended one only reports that it is. Otherwise, after any requested
+// resync and the give-up check for exhausted retries, the source must pass
+// the namespace scope, permission, signature, decryption, scan and quota
+// checks. Source changes are then held by the debounce, the sync windows
+// and the rate limit, in that order, before the destinations are applied
+// and those dropped from the spec are pruned. Failures are retried with
+// the workqueue's backoff.
+func (r *ConfigMapSyncReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reconcileErr error) {
+	// With sharding, syncs of shards held by other replicas are left to them
+	if r.Shards != nil {
+		var done func()
+		var owned bool
+		if ctx, done, owned = r.Shards.Begin(ctx, req.Namespace); !owned {
+			return ctrl.Result{}, nil
+		}
+		defer done()
+	}
 
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the ConfigMapSync object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
-//
-// The controller follows a one-way sync pattern: source -> destination
-// If the source ConfigMap changes, it will be reflected in the destination
-func (r *ConfigMapSyncReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// Trace the whole reconcile; the logger below carries its trace ID
+	ctx, span := r.startReconcileSpan(ctx, req.NamespacedName)
+	defer func() {
+		endSpan(span, reconcileErr)
+	}()
 
 	// Initialize logger for this reconciliation run
 	logger := log.FromContext(ctx)
 
 	// Step 1: Fetch the ConfigMapSync resource that triggered this reconciliation
-	configMapSync := &appsv1.ConfigMapSync{}
+	configMapSync := &appsv2.ConfigMapSync{}
 	if err := r.Get(ctx, req.NamespacedName, configMapSync); err != nil {
 		// Resource might have been deleted, ignore error
 		logger.Error(err, "Failed to fetch ConfigMapSync resource")
+		if apierrors.IsNotFound(err) && r.backoff != nil {
+			r.backoff.setPolicy(req, nil)
+		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	annotateSyncSpan(span, configMapSync)
+
+	// Failed attempts of this sync back off with its own retry policy
+	if r.backoff != nil {
+		r.backoff.setPolicy(req, retryOptions(configMapSync))
+	}
+
+	// Status is only written when this reconcile changed it
+	originalStatus := configMapSync.Status.DeepCopy()
 
 	// Check if someone wants to delete this ConfigMapSync
 	if configMapSync.DeletionTimestamp != nil {
 		logger.Info("ConfigMapSync is being deleted, starting cleanup")
-		destinationKey := types.NamespacedName{
-			Name:      configMapSync.Spec.ConfigMapName,
-			Namespace: configMapSync.Spec.DestinationNamespace,
-		}
-		destinationConfigMap := &corev1.ConfigMap{}
-		err := r.Get(ctx, destinationKey, destinationConfigMap)
-		if err != nil {
-			if apierrors.IsNotFound(err) {
-				logger.Info("Destination ConfigMap not found, skipping cleanup")
-			}
-		}
-		if err == nil {
-			logger.Info("Destination ConfigMap found and deleting it")
-			err := r.Delete(ctx, destinationConfigMap)
-			if err != nil {
-				logger.Error(err, "Failed to delete destination ConfigMap")
+		// Clean up everything the sync has written, including destinations
+		// that have since been dropped from the spec
+		for _, destinationKey := range managedDestinationKeys(configMapSync) {
+			if err := r.cleanupDestination(ctx, configMapSync, destinationKey); err != nil {
+				logger.Error(err, "Failed to clean up destination ConfigMap", "destinationKey", destinationKey)
 				return ctrl.Result{}, err
 			}
-			logger.Info("Destination ConfigMap deleted successfully")
+		}
+
+		forgetSyncMetrics(configMapSync)
+		if r.sources != nil {
+			r.sources.forget(req.NamespacedName)
 		}
 
 		// Remove finalizer from ConfigMapSync
 		logger.Info("Removing configmapsync finalizer")
-		controllerutil.RemoveFinalizer(configMapSync, ConfigMapSyncFinalizer)
-		err = r.Update(ctx, configMapSync)
-		if err != nil {
+		if err := r.patchFinalizer(ctx, configMapSync, controllerutil.RemoveFinalizer); err != nil {
 			logger.Error(err, "Failed to remove finalizer from ConfigMapSync")
 			return ctrl.Result{}, err
 		}
@@ -115,211 +225,489 @@ func (r *ConfigMapSyncReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	// Add finalizer if not present
 	if !controllerutil.ContainsFinalizer(configMapSync, ConfigMapSyncFinalizer) {
 		logger.Info("Adding Finalizer to ConfigMapSync")
-		controllerutil.AddFinalizer(configMapSync, ConfigMapSyncFinalizer)
-		err := r.Update(ctx, configMapSync)
-		if err != nil {
+		if err := r.patchFinalizer(ctx, configMapSync, controllerutil.AddFinalizer); err != nil {
 			logger.Error(err, "Failed to add finalizer")
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{}, nil
+		// The predicates filter out this metadata-only update, so carry on
+		// with the sync instead of waiting for the resulting event
 	}
 
+	// Record the shard so that each replica's share can be listed by label
+	if r.Shards != nil {
+		if err := r.patchShardLabel(ctx, configMapSync); err != nil {
+			logger.Error(err, "Failed to label ConfigMapSync with its shard")
+			return ctrl.Result{}, err
+		}
+	}
+
+	// A suspended sync only reports that it is suspended
+	if configMapSync.Spec.Suspend {
+		return r.suspendSync(ctx, configMapSync, originalStatus)
+	}
+	meta.RemoveStatusCondition(&configMapSync.Status.Conditions, TypeSuspended)
+
+	// Every path from here on is a sync attempt and is recorded in metrics
+	syncStart := time.Now()
+	defer func() {
+		r.recordSyncMetrics(configMapSync, syncStart, reconcileErr)
+	}()
+
 	// Log the sync operation details for observability
 	logger.Info("Processing ConfigMapSync",
-		"sourceNameSpace", configMapSync.Spec.SourceNamespace,
-		"destinationNameSpace", configMapSync.Spec.DestinationNamespace,
-		"configMapName", configMapSync.Spec.ConfigMapName,
+		"source", sourceKey(configMapSync),
+		"destinations", destinationKeys(configMapSync),
 	)
 
+	// A new requested-at value forces a full resync: the source is read
+	// from the API server and every destination is written, even when it
+	// looks up to date. The value is recorded whatever the outcome
+	requestedAt, resync := resyncRequested(configMapSync)
+	if resync {
+		logger.Info("Full resync requested", "requestedAt", requestedAt)
+		if r.sources != nil {
+			r.sources.invalidate(sourceKey(configMapSync))
+		}
+		configMapSync.Status.LastHandledReconcileAt = requestedAt
+	}
+
+	// A sync that exhausted its retries stays Stalled until its spec changes
+	// or a resync is requested; either starts a fresh round of attempts
+	if stalled := exhaustedCondition(configMapSync); stalled != nil {
+		if stalled.ObservedGeneration == configMapSync.Generation && !resync {
+			logger.Info("Retries exhausted, waiting for a spec change or a requested resync")
+			return ctrl.Result{}, nil
+		}
+		configMapSync.Status.RetryCount = 0
+	}
+
+	// Step 1a: Check that the sync stays within the namespaces the operator
+	// is restricted to; it could not read or write the others anyway
+	if r.Namespaces.Restricted() {
+		if violations := r.Namespaces.violations(configMapSync); len(violations) > 0 {
+			message := "Sync reaches outside the operator's namespaces: " + strings.Join(violations, "; ")
+			logger.Info("ConfigMapSync references namespaces outside the operator's scope, skipping sync", "violations", violations)
+			r.setCondition(configMapSync, TypeNamespacesInScope, metav1.ConditionFalse, "NamespaceOutOfScope", message)
+			return r.blockSync(ctx, configMapSync, originalStatus, "NamespaceOutOfScope", message)
+		}
+		r.setCondition(configMapSync, TypeNamespacesInScope, metav1.ConditionTrue, "NamespacesWatched", "Every namespace of this sync is watched by the operator")
+	}
+
+	// Step 1b: Check that the operator may perform every operation this sync
+	// needs, so a namespace-scoped deployment reports exactly what is missing
+	if r.permissions != nil {
+		missing, err := r.permissions.missing(ctx, requiredPermissions(configMapSync))
+		if err != nil {
+			logger.Error(err, "Failed to run permission preflight")
+			return ctrl.Result{}, err
+		}
+		if len(missing) > 0 {
+			message := "Operator is missing permissions: " + describeRequirements(missing)
+			logger.Info("Insufficient permissions for ConfigMapSync, skipping sync", "missing", describeRequirements(missing))
+			r.setCondition(configMapSync, TypePermissionsSufficient, metav1.ConditionFalse, "MissingPermissions", message)
+			return r.blockSync(ctx, configMapSync, originalStatus, "InsufficientPermissions", message)
+		}
+		r.setCondition(configMapSync, TypePermissionsSufficient, metav1.ConditionTrue, "PermissionsGranted", "Operator holds every permission this sync needs")
+	}
+
 	// Step 2: Fetch the source ConfigMap from the source namespace
 	sourceConfigMap := &corev1.ConfigMap{}
-	sourceKey := types.NamespacedName{
-		Name:      configMapSync.Spec.ConfigMapName,
-		Namespace: configMapSync.Spec.SourceNamespace,
-	}
+	sourceKey := sourceKey(configMapSync)
 
-	err := r.Get(ctx, sourceKey, sourceConfigMap)
+	err := r.traceCall(ctx, "ConfigMap.GetSource", sourceKey, func(ctx context.Context) error {
+		return r.getSource(ctx, configMapSync, sourceConfigMap)
+	})
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			logger.Info("Source ConfigMap not found, skipping sync", "sourceKey", sourceKey)
+			r.recordEvent(configMapSync, nil, corev1.EventTypeWarning, EventReasonSourceMissing,
+				fmt.Sprintf("Source ConfigMap %s not found", sourceKey))
 			// Update status to show source not found
-			configMapSync.Status.SyncStatus = "Failed"
+			r.setCondition(configMapSync, TypeSynced, metav1.ConditionFalse, "SourceNotFound", "Source ConfigMap not found")
+			r.setCondition(configMapSync, TypeSourceAvailable, metav1.ConditionFalse, "SourceNotFound", "Source ConfigMap does not exist")
+			r.markStalled(configMapSync, "SourceNotFound", fmt.Sprintf("Source ConfigMap %s not found", sourceKey))
+			configMapSync.Status.SyncStatus = appsv2.SyncFailed
 			configMapSync.Status.Message = "Source ConfigMap not found"
-			configMapSync.Status.SourceExists = false
-			configMapSync.Status.DestinationExists = false
-			configMapSync.Status.LastSyncTime = time.Now().Format(time.RFC3339)
-			err = r.Status().Update(ctx, configMapSync)
-			if err != nil {
+			configMapSync.Status.Source.Exists = false
+			configMapSync.Status.LastSyncTime = ptr.To(metav1.Now())
+			if err := r.updateStatus(ctx, configMapSync, originalStatus); err != nil {
 				logger.Error(err, "Failed to update ConfigMapSync status")
+				return ctrl.Result{}, err
 			}
-			return ctrl.Result{RequeueAfter: time.Minute * 5}, nil
+			return ctrl.Result{RequeueAfter: recheckInterval(configMapSync)}, nil
 		}
 
-		configMapSync.Status.RetryCount++
-		backoffDelay := r.calculateBackoffDuration(configMapSync.Status.RetryCount, time.Second*30)
-		logger.Error(err, "Failed to fetch source ConfigMap, retrying with backoff",
+		// The returned error requeues with the workqueue's backoff;
+		// RetryCount only reports its count
+		attempts := r.failedAttempts(configMapSync)
+		configMapSync.Status.RetryCount = attempts
+		logger.Info("Failed to fetch source ConfigMap, retrying with backoff",
 			"sourceKey", sourceKey,
 			"retryCount", configMapSync.Status.RetryCount,
-			"retryAfter", backoffDelay,
 		)
 
 		r.setCondition(configMapSync, TypeSynced, metav1.ConditionFalse, "SyncFailed", "Failed to fetch source ConfigMap")
 		r.setCondition(configMapSync, TypeSourceAvailable, metav1.ConditionFalse, "FetchError", "Error accessing source ConfigMap")
-		r.setCondition(configMapSync, TypeReady, metav1.ConditionFalse, "NotReady", "Source ConfigMap fetch failed")
-		configMapSync.Status.SyncStatus = "Failed"
+		r.markReconciling(configMapSync, "RetryingAfterError", "Source ConfigMap fetch failed, retrying")
+		configMapSync.Status.SyncStatus = appsv2.SyncFailed
 		configMapSync.Status.Message = "Failed to fetch source ConfigMap"
-		configMapSync.Status.SourceExists = false
-		configMapSync.Status.DestinationExists = false
-		configMapSync.Status.LastSyncTime = time.Now().Format(time.RFC3339)
-		err = r.Status().Update(ctx, configMapSync)
-		if err != nil {
-			logger.Error(err, "Failed to update ConfigMapSync status")
+		configMapSync.Status.Source.Exists = false
+		configMapSync.Status.LastSyncTime = ptr.To(metav1.Now())
+		err = fmt.Errorf("fetching source ConfigMap %s: %w", sourceKey, err)
+		if r.exhaustRetries(configMapSync, attempts, err) {
+			return r.stopRetrying(ctx, configMapSync, originalStatus, err)
+		}
+		if statusErr := r.updateStatus(ctx, configMapSync, originalStatus); statusErr != nil {
+			logger.Error(statusErr, "Failed to update ConfigMapSync status")
+			err = errors.Join(err, statusErr)
 		}
-		return ctrl.Result{RequeueAfter: backoffDelay}, nil
+		return ctrl.Result{}, err
 	}
 
 	logger.Info("Source ConfigMap fetched successfully", "sourceKey", sourceKey, "dataKeys", len(sourceConfigMap.Data))
+	r.setCondition(configMapSync, TypeSourceAvailable, metav1.ConditionTrue, "SourceFound", "Source ConfigMap exists and accessible")
+	configMapSync.Status.Source.Exists = true
 
-	// Step 3: Prepare the destination ConfigMap structure with source data
-	destinationConfigMap := &corev1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      configMapSync.Spec.ConfigMapName,
-			Namespace: configMapSync.Spec.DestinationNamespace,
-			// TODO: Add labels/annotations to track the sync relationship
-		},
-		Data: sourceConfigMap.Data, // Copy all data from source
-	}
+	// Step 2a: Verify the source was signed by a trusted key. Anything unsigned
+	// or badly signed stops here so the destination keeps the last verified data
+	if verification := configMapSync.Spec.SignatureVerification; verification != nil {
+		trustedKeys, err := r.loadTrustedKeys(ctx, configMapSync.Namespace, verification.PublicKeysRef)
+		if err != nil {
+			logger.Error(err, "Failed to load trusted public keys", "publicKeysRef", verification.PublicKeysRef.Name)
+			r.setCondition(configMapSync, TypeSignatureVerified, metav1.ConditionFalse, "TrustedKeysUnavailable", err.Error())
+			return r.blockSync(ctx, configMapSync, originalStatus, "SignatureNotVerified", "Trusted public keys unavailable: "+err.Error())
+		}
 
-	if destinationConfigMap.Labels == nil {
-		destinationConfigMap.Labels = make(map[string]string)
+		if err := verifySourceSignature(sourceConfigMap, trustedKeys); err != nil {
+			reason := "InvalidSignature"
+			if errors.Is(err, errUnsigned) {
+				reason = "Unsigned"
+			}
+			logger.Info("Source ConfigMap signature verification failed, keeping last verified destination",
+				"sourceKey", sourceKey,
+				"reason", reason,
+			)
+			r.setCondition(configMapSync, TypeSignatureVerified, metav1.ConditionFalse, reason, err.Error())
+			return r.blockSync(ctx, configMapSync, originalStatus, "SignatureNotVerified", err.Error())
+		}
+		r.setCondition(configMapSync, TypeSignatureVerified, metav1.ConditionTrue, "SignatureValid", "Source ConfigMap signed by a trusted key")
+	} else {
+		meta.RemoveStatusCondition(&configMapSync.Status.Conditions, TypeSignatureVerified)
 	}
-	destinationConfigMap.Labels["configmapsync.apps.kapendra.com/sync-name"] = configMapSync.Name
-	destinationConfigMap.Labels["configmapsync.apps.kapendra.com/sync-namespace"] = configMapSync.Namespace
-	destinationConfigMap.Labels["configmapsync.apps.kapendra.com/managed-by"] = "configmapsync-controller"
 
-	// Add source hash for change detection
-	if destinationConfigMap.Annotations == nil {
-		destinationConfigMap.Annotations = make(map[string]string)
-	}
-	sourceHash := r.calculateSourceHash(sourceConfigMap.Data)
-	destinationConfigMap.Annotations["configmapsync.apps.kapendra.com/source-hash"] = sourceHash
-	destinationConfigMap.Annotations["configmapsync.apps.kapendra.com/last-sync"] = time.Now().Format(time.RFC3339)
+	// Step 2b: Decrypt SOPS-encrypted values so that only the destination
+	// carries plaintext. Nothing derived from the plaintext is ever logged
+	syncData := sourceConfigMap.Data
+	if decryption := configMapSync.Spec.Decryption; decryption != nil && isSOPSEncrypted(sourceConfigMap) {
+		identities, err := r.loadAgeIdentities(ctx, configMapSync.Namespace, decryption.SecretRef.Name)
+		if err != nil {
+			logger.Error(err, "Failed to load decryption identities", "secretRef", decryption.SecretRef.Name)
+			r.setCondition(configMapSync, TypeDecrypted, metav1.ConditionFalse, "IdentitiesUnavailable", err.Error())
+			return r.blockSync(ctx, configMapSync, originalStatus, "DecryptionFailed", "Decryption identities unavailable: "+err.Error())
+		}
 
-	// Step 4: Check if destination ConfigMap already exists
-	destinationKey := types.NamespacedName{
-		Name:      configMapSync.Spec.ConfigMapName,
-		Namespace: configMapSync.Spec.DestinationNamespace,
+		syncData, err = decryptSOPSData(sourceConfigMap, identities)
+		if err != nil {
+			logger.Info("Failed to decrypt source ConfigMap, keeping previous destination",
+				"sourceKey", sourceKey,
+				"error", err.Error(),
+			)
+			r.setCondition(configMapSync, TypeDecrypted, metav1.ConditionFalse, "DecryptionFailed", err.Error())
+			return r.blockSync(ctx, configMapSync, originalStatus, "DecryptionFailed", err.Error())
+		}
+		r.setCondition(configMapSync, TypeDecrypted, metav1.ConditionTrue, "DecryptionSucceeded", "Encrypted source values decrypted")
+	} else {
+		meta.RemoveStatusCondition(&configMapSync.Status.Conditions, TypeDecrypted)
 	}
 
-	existingConfigMap := &corev1.ConfigMap{}
-	err = r.Get(ctx, destinationKey, existingConfigMap)
-
-	if err != nil {
-		if apierrors.IsNotFound(err) {
-			// Case 1: Destination ConfigMap doesn't exist - create it
-			logger.Info("Destination ConfigMap not found, creating new one", "destinationKey", destinationKey)
-			err = r.Create(ctx, destinationConfigMap)
-			if err != nil {
-				configMapSync.Status.RetryCount++
-				backoffDelay := r.calculateBackoffDuration(configMapSync.Status.RetryCount, time.Minute*1)
-				logger.Error(err, "Failed to create destination ConfigMap, retrying with backoff",
-					"destinationKey", destinationKey,
-					"retryCount", configMapSync.Status.RetryCount,
-					"retryAfter", backoffDelay)
-				return ctrl.Result{RequeueAfter: backoffDelay}, nil
+	// Step 2c: Scan the data for leaked credentials before it is copied
+	// into namespaces with a wider audience
+	scanMode := configMapSync.Spec.SensitiveDataScanMode
+	if scanMode == appsv2.SensitiveDataScanWarn || scanMode == appsv2.SensitiveDataScanBlock {
+		findings := scanForSensitiveData(syncData, sourceConfigMap.BinaryData, configMapSync.Spec.SensitiveDataScanAllowlist)
+		if len(findings) > 0 {
+			message := "Sensitive data detected in source keys: " + describeFindings(findings)
+			r.setCondition(configMapSync, TypeSensitiveDataDetected, metav1.ConditionTrue, "CredentialPatternMatched", message)
+
+			if scanMode == appsv2.SensitiveDataScanBlock {
+				logger.Info("Sensitive data detected in source ConfigMap, blocking sync",
+					"sourceKey", sourceKey,
+					"keys", findingKeys(findings),
+				)
+				return r.blockSync(ctx, configMapSync, originalStatus, "SensitiveDataDetected", message)
 			}
-			logger.Info("Destination ConfigMap created successfully", "destinationKey", destinationKey)
+
+			logger.Info("Sensitive data detected in source ConfigMap, syncing anyway in warn mode",
+				"sourceKey", sourceKey,
+				"keys", findingKeys(findings),
+			)
 		} else {
-			// Unexpected error occurred while fetching destination ConfigMap
-			logger.Error(err, "Failed to fetch destination ConfigMap", "destinationKey", destinationKey)
-			return ctrl.Result{}, err
+			r.setCondition(configMapSync, TypeSensitiveDataDetected, metav1.ConditionFalse, "NoFindings", "No sensitive data detected in source ConfigMap")
 		}
 	} else {
-		// Case 2: Destination ConfigMap exists - update it with source data
-		logger.Info("Destination ConfigMap found, updating with source data", "destinationKey", destinationKey)
+		meta.RemoveStatusCondition(&configMapSync.Status.Conditions, TypeSensitiveDataDetected)
+	}
 
-		// Preserve existing ObjectMeta but update Data section
-		existingConfigMap.Data = sourceConfigMap.Data
+	// Step 3: Prepare the destination ConfigMaps with the source data
+	sourceHash := contentHash(syncData, sourceConfigMap.BinaryData, syncLabels(configMapSync))
+	var desiredConfigMaps []*corev1.ConfigMap
+	for _, destinationKey := range destinationKeys(configMapSync) {
+		desiredConfigMaps = append(desiredConfigMaps,
+			desiredDestination(configMapSync, destinationKey, syncData, sourceConfigMap.BinaryData, sourceHash))
+	}
 
-		// Update hash and sync timestamp
-		if existingConfigMap.Annotations == nil {
-			existingConfigMap.Annotations = make(map[string]string)
-		}
-		sourceHash := r.calculateSourceHash(sourceConfigMap.Data)
-		existingConfigMap.Annotations["configmapsync.apps.kapendra.com/source-hash"] = sourceHash
-		existingConfigMap.Annotations["configmapsync.apps.kapendra.com/last-sync"] = time.Now().Format(time.RFC3339)
+	// Step 3a: Enforce tenant quotas before anything is written, so an
+	// oversized change is rejected as a whole rather than partially applied
+	violation, err := r.checkQuota(ctx, configMapSync, desiredConfigMaps)
+	if err != nil {
+		logger.Error(err, "Failed to evaluate sync quotas")
+		return ctrl.Result{}, err
+	}
+	if violation != "" {
+		logger.Info("Sync quota exceeded, skipping write", "sourceKey", sourceKey, "violation", violation)
+		r.setCondition(configMapSync, TypeQuotaExceeded, metav1.ConditionTrue, "QuotaExceeded", violation)
+		return r.blockSync(ctx, configMapSync, originalStatus, "QuotaExceeded", violation)
+	}
+	r.setCondition(configMapSync, TypeQuotaExceeded, metav1.ConditionFalse, "WithinQuota", "Sync is within configured quotas")
+
+	// Step 3b: Let bursts of source changes settle before propagating them
+	if result, held, err := r.debounceChange(ctx, configMapSync, originalStatus, sourceConfigMap, sourceHash); held {
+		return result, err
+	}
+
+	// Step 3c: Hold changes outside the sync windows and during freezes,
+	// and come back when they next allow them
+	if result, held, err := r.holdOutsideWindow(ctx, configMapSync, originalStatus, sourceConfigMap, desiredConfigMaps); held {
+		return result, err
+	}
+
+	// Step 3d: Cap the source changes propagated per hour
+	if result, held, err := r.limitRate(ctx, configMapSync, originalStatus, sourceConfigMap, sourceHash); held {
+		return result, err
+	}
 
-		err = r.Update(ctx, existingConfigMap)
+	// Step 4: Create or update every destination. A failing destination
+	// does not hold back the others
+	destinationStatuses := make([]appsv2.DestinationStatus, 0, len(desiredConfigMaps))
+	var failedDestinations []string
+	var destinationErrs []error
+	conflicts := 0
+	for _, destinationConfigMap := range desiredConfigMaps {
+		destinationStatus, err := r.syncDestination(ctx, configMapSync, sourceConfigMap, destinationConfigMap, resync)
+		destinationStatuses = append(destinationStatuses, destinationStatus)
+		if err == nil {
+			addManagedObject(configMapSync, client.ObjectKeyFromObject(destinationConfigMap))
+		}
 		if err != nil {
-			configMapSync.Status.RetryCount++
-			backoffDelay := r.calculateBackoffDuration(configMapSync.Status.RetryCount, time.Minute*1)
-			logger.Error(err, "Failed to update destination ConfigMap, retrying with backoff",
-				"destinationKey", destinationKey,
-				"retryCount", configMapSync.Status.RetryCount,
-				"retryAfter", backoffDelay)
-			return ctrl.Result{RequeueAfter: backoffDelay}, nil
+			destinationKey := client.ObjectKeyFromObject(destinationConfigMap).String()
+			failedDestinations = append(failedDestinations, destinationKey)
+			destinationErrs = append(destinationErrs, fmt.Errorf("syncing destination ConfigMap %s: %w", destinationKey, err))
+			if apierrors.IsConflict(err) {
+				conflicts++
+			}
+		}
+	}
+	configMapSync.Status.Destinations = destinationStatuses
+
+	if len(failedDestinations) > 0 {
+		message := "Failed to sync destination ConfigMaps: " + strings.Join(failedDestinations, ", ")
+		attempts := r.failedAttempts(configMapSync)
+		configMapSync.Status.RetryCount = attempts
+		configMapSync.Status.SyncStatus = appsv2.SyncFailed
+		configMapSync.Status.Message = message
+		configMapSync.Status.LastSyncTime = ptr.To(metav1.Now())
+		logger.Info("Failed to sync destination ConfigMaps, retrying with backoff",
+			"destinations", failedDestinations,
+			"retryCount", configMapSync.Status.RetryCount)
+		if conflicts == len(failedDestinations) {
+			// Field ownership conflicts persist until the other manager lets
+			// go of the fields or spec.force is set
+			message = "Destination fields are owned by another field manager: " + strings.Join(failedDestinations, ", ")
+			configMapSync.Status.Message = message
+			r.setCondition(configMapSync, TypeSynced, metav1.ConditionFalse, "FieldConflict", message)
+			r.markStalled(configMapSync, "FieldConflict", message)
+		} else {
+			r.setCondition(configMapSync, TypeSynced, metav1.ConditionFalse, "SyncFailed", message)
+			r.markReconciling(configMapSync, "RetryingAfterError", message)
+		}
+		if syncErr := errors.Join(destinationErrs...); r.exhaustRetries(configMapSync, attempts, syncErr) {
+			return r.stopRetrying(ctx, configMapSync, originalStatus, syncErr)
+		}
+		if err := r.updateStatus(ctx, configMapSync, originalStatus); err != nil {
+			logger.Error(err, "Failed to update ConfigMapSync status")
+			destinationErrs = append(destinationErrs, err)
+		}
+		return ctrl.Result{}, errors.Join(destinationErrs...)
+	}
+	r.recordPropagation(configMapSync, configMapSync.Status.Source.SyncedHash != sourceHash)
+	recordSourceState(configMapSync, sourceConfigMap, sourceHash, syncData)
+
+	// Step 5: Clean up the destinations that were dropped from the spec
+	if err := r.pruneDestinations(ctx, configMapSync); err != nil {
+		logger.Error(err, "Failed to clean up removed destination ConfigMaps")
+		r.markReconciling(configMapSync, "CleanupFailed", "Failed to clean up removed destination ConfigMaps: "+err.Error())
+		if statusErr := r.updateStatus(ctx, configMapSync, originalStatus); statusErr != nil {
+			logger.Error(statusErr, "Failed to update ConfigMapSync status")
+			err = errors.Join(err, statusErr)
 		}
-		logger.Info("Destination ConfigMap updated successfully", "destinationKey", destinationKey)
+		return ctrl.Result{}, err
 	}
 
 	configMapSync.Status.RetryCount = 0 // Update status after successful sync
-	configMapSync.Status.LastSyncTime = time.Now().Format(time.RFC3339)
+	configMapSync.Status.Pending = nil
+	configMapSync.Status.LastSyncTime = ptr.To(metav1.Now())
 	r.setCondition(configMapSync, TypeSynced, metav1.ConditionTrue, "SyncSucceeded", "ConfigMap synced successfully")
 	r.setCondition(configMapSync, TypeSourceAvailable, metav1.ConditionTrue, "SourceFound", "Source ConfigMap exists and accessible")
-	r.setCondition(configMapSync, TypeReady, metav1.ConditionTrue, "AllComponentsReady", "All sync components are functioning properly")
-	configMapSync.Status.SyncStatus = "Success"
+	r.markReady(configMapSync, "AllComponentsReady", "All sync components are functioning properly")
+	configMapSync.Status.SyncStatus = appsv2.SyncSucceeded
 	configMapSync.Status.Message = "ConfigMap synced successfully"
-	configMapSync.Status.SourceExists = true
-	configMapSync.Status.DestinationExists = true
+	configMapSync.Status.Source.Exists = true
 
-	err = r.Status().Update(ctx, configMapSync)
-	if err != nil {
+	if err := r.updateStatus(ctx, configMapSync, originalStatus); err != nil {
+		// The destinations are synced; retry so the status catches up
 		logger.Error(err, "Failed to update ConfigMapSync status")
-		// Don't return error - sync succeeded even if status update failed
+		return ctrl.Result{}, err
 	}
 	// Sync operation completed successfully
 	logger.Info("ConfigMap sync completed successfully",
 		"sourceKey", sourceKey,
-		"destinationKey", destinationKey,
+		"destinations", len(desiredConfigMaps),
 	)
 
-	return ctrl.Result{}, nil
+	// Come back to verify the destinations even if nothing changes
+	return resyncResult(configMapSync), nil
+}
+
+// blockSync records why the source is not being propagated and requeues.
+// The destination is left untouched so it keeps the last good content.
+func (r *ConfigMapSyncReconciler) blockSync(ctx context.Context, configMapSync *appsv2.ConfigMapSync, originalStatus *appsv2.ConfigMapSyncStatus, reason string, message string) (ctrl.Result, error) {
+	r.setCondition(configMapSync, TypeSynced, metav1.ConditionFalse, reason, message)
+	r.markStalled(configMapSync, reason, "Sync blocked: "+message)
+	configMapSync.Status.SyncStatus = appsv2.SyncFailed
+	configMapSync.Status.Message = message
+	configMapSync.Status.LastSyncTime = ptr.To(metav1.Now())
+	r.recordEvent(configMapSync, nil, corev1.EventTypeWarning, EventReasonSyncBlocked, message)
+	if err := r.updateStatus(ctx, configMapSync, originalStatus); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update ConfigMapSync status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: recheckInterval(configMapSync)}, nil
+}
+
+// patchFinalizer adds or removes the controller's finalizer with a merge
+// patch. The optimistic lock keeps the patch from dropping finalizers that
+// others changed concurrently; a conflict is retried by the workqueue.
+func (r *ConfigMapSyncReconciler) patchFinalizer(ctx context.Context, configMapSync *appsv2.ConfigMapSync, mutate func(client.Object, string) bool) error {
+	patch := client.MergeFromWithOptions(configMapSync.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	if !mutate(configMapSync, ConfigMapSyncFinalizer) {
+		return nil
+	}
+	return r.Patch(ctx, configMapSync, patch)
+}
+
+// patchShardLabel sets ShardLabel to the ConfigMapSync's current shard.
+func (r *ConfigMapSyncReconciler) patchShardLabel(ctx context.Context, configMapSync *appsv2.ConfigMapSync) error {
+	shard := strconv.Itoa(r.Shards.ShardFor(configMapSync.Namespace))
+	if configMapSync.Labels[ShardLabel] == shard {
+		return nil
+	}
+	patch := client.MergeFrom(configMapSync.DeepCopy())
+	if configMapSync.Labels == nil {
+		configMapSync.Labels = map[string]string{}
+	}
+	configMapSync.Labels[ShardLabel] = shard
+	return r.Patch(ctx, configMapSync, patch)
 }
 
-func (r *ConfigMapSyncReconciler) setCondition(configMapSync *appsv1.ConfigMapSync, conditionType string, status metav1.ConditionStatus, reason string, message string) {
+func (r *ConfigMapSyncReconciler) setCondition(configMapSync *appsv2.ConfigMapSync, conditionType string, status metav1.ConditionStatus, reason string, message string) {
+	// LastTransitionTime is left to SetStatusCondition, which only moves it
+	// when the status actually flips
 	condition := metav1.Condition{
 		Type:               conditionType,
 		Status:             status,
-		LastTransitionTime: metav1.Now(),
+		ObservedGeneration: configMapSync.Generation,
 		Reason:             reason,
 		Message:            message,
 	}
 	meta.SetStatusCondition(&configMapSync.Status.Conditions, condition)
 }
 
-func (r *ConfigMapSyncReconciler) calculateBackoffDuration(retryCount int, baseDelay time.Duration) time.Duration {
-	if retryCount == 0 {
-		return baseDelay
+// SetupWithManager sets up the controller with the Manager.
+// This configures the controller to watch ConfigMapSync resources
+// and triggers reconciliation when they change.
+func (r *ConfigMapSyncReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.permissions == nil {
+		r.permissions = newPermissionCache(mgr.GetClient(), permissionCacheTTL)
+	}
+	if err := mgr.Add(&permissionSummary{client: mgr.GetClient(), permissions: r.permissions, namespaces: r.Namespaces}); err != nil {
+		return err
+	}
+
+	// Sources are watched as metadata only, in a cache of their own because
+	// the manager cache only holds the labelled destination ConfigMaps
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &appsv2.ConfigMapSync{}, sourceIndexField,
+		func(obj client.Object) []string {
+			return []string{sourceKey(obj.(*appsv2.ConfigMapSync)).String()}
+		}); err != nil {
+		return err
+	}
+	metadataCache, err := cache.New(mgr.GetConfig(), cache.Options{
+		Scheme:            mgr.GetScheme(),
+		Mapper:            mgr.GetRESTMapper(),
+		DefaultNamespaces: r.Namespaces.CacheNamespaces(),
+		DefaultTransform:  cache.TransformStripManagedFields(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := mgr.Add(metadataCache); err != nil {
+		return err
+	}
+	if r.sources == nil {
+		r.sources = newSourceCache(metadataCache, mgr.GetAPIReader())
 	}
-	backoff := baseDelay * time.Duration(1<<uint(retryCount))
-	maxDelay := time.Minute * 10
 
-	if backoff > maxDelay {
-		return maxDelay
+	sourceChanges := source.Kind(metadataCache, configMapMetadata(),
+		handler.TypedEnqueueRequestsFromMapFunc(r.syncsForSource),
+		predicate.TypedResourceVersionChangedPredicate[*metav1.PartialObjectMetadata]{})
+
+	// Watch ConfigMapSync resources, ignoring status-only updates, and the
+	// ConfigMaps they read from. Failed reconciles are retried with a
+	// jittered per-item exponential backoff
+	r.backoff = newRetryBackoff(r.Retry)
+	options := controller.Options{RateLimiter: newRetryRateLimiter(r.backoff)}
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&appsv2.ConfigMapSync{}, builder.WithPredicates(syncChangedPredicate())).
+		WatchesRawSource(sourceChanges).
+		Named("configmapsync") // Give the controller a name
+
+	// Every replica runs the controller; the shard leases decide which
+	// ConfigMapSyncs it reconciles, and newly acquired shards are enqueued
+	if r.Shards != nil {
+		if err := mgr.Add(r.Shards); err != nil {
+			return err
+		}
+		options.NeedLeaderElection = ptr.To(false)
+		b = b.WatchesRawSource(source.Channel(r.Shards.events, &handler.EnqueueRequestForObject{}))
 	}
-	return backoff
+	if r.SyncFreezes {
+		b = b.Watches(&appsv2.SyncFreeze{}, handler.EnqueueRequestsFromMapFunc(r.syncsForFreeze))
+	}
+	return b.WithOptions(options).Complete(r)
 }
 
-func (r *ConfigMapSyncReconciler) calculateSourceHash(sourceData map[string]string) string {
-	return fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("%v", sourceData))))
+// clock returns the current time.
+func (r *ConfigMapSyncReconciler) clock() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
 }
 
-// SetupWithManager sets up the controller with the Manager.
-// This configures the controller to watch ConfigMapSync resources
-// and triggers reconciliation when they change.
-func (r *ConfigMapSyncReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&appsv1.ConfigMapSync{}). // Watch ConfigMapSync resources
-		Named("configmapsync").       // Give the controller a name
-		Complete(r)
+// syncChangedPredicate passes spec changes, which move the generation, and
+// annotation changes, which carry requests such as a forced resync. Status
+// and other metadata-only updates, including the controller's own status
+// writes, do not trigger a reconcile.
+func syncChangedPredicate() predicate.Predicate {
+	return predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{})
 }
@@ -21,16 +21,22 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	appsv1 "operators/src/ConfigMapSync/api/v1"
+	appsv2 "operators/src/ConfigMapSync/api/v2"
 )
 
 var _ = Describe("ConfigMapSync Controller", func() {
+	useEnvTest()
+
 	Context("When reconciling a resource", func() {
 		const resourceName = "test-resource"
 
@@ -40,18 +46,21 @@ var _ = Describe("ConfigMapSync Controller", func() {
 			Name:      resourceName,
 			Namespace: "default", // TODO(user):Modify as needed
 		}
-		configmapsync := &appsv1.ConfigMapSync{}
+		configmapsync := &appsv2.ConfigMapSync{}
 
 		BeforeEach(func() {
 			By("creating the custom resource for the Kind ConfigMapSync")
 			err := k8sClient.Get(ctx, typeNamespacedName, configmapsync)
 			if err != nil && errors.IsNotFound(err) {
-				resource := &appsv1.ConfigMapSync{
+				resource := &appsv2.ConfigMapSync{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      resourceName,
 						Namespace: "default",
 					},
-					// TODO(user): Specify other spec details if needed.
+					Spec: appsv2.ConfigMapSyncSpec{
+						Source:       appsv2.SourceReference{Namespace: "default", Name: "test-source"},
+						Destinations: []appsv2.DestinationReference{{Namespace: "default", Name: "test-destination"}},
+					},
 				}
 				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
 			}
@@ -59,7 +68,7 @@ var _ = Describe("ConfigMapSync Controller", func() {
 
 		AfterEach(func() {
 			// TODO(user): Cleanup logic after each test, like removing the resource instance.
-			resource := &appsv1.ConfigMapSync{}
+			resource := &appsv2.ConfigMapSync{}
 			err := k8sClient.Get(ctx, typeNamespacedName, resource)
 			Expect(err).NotTo(HaveOccurred())
 
@@ -81,4 +90,169 @@ var _ = Describe("ConfigMapSync Controller", func() {
 			// Example: If you expect a certain status condition after reconciliation, verify it here.
 		})
 	})
+
+	Context("When other field managers write the destination", func() {
+		ctx := context.Background()
+		syncKey := types.NamespacedName{Name: "ssa-sync", Namespace: "default"}
+		sourceKey := types.NamespacedName{Name: "ssa-source", Namespace: "default"}
+		destinationKey := types.NamespacedName{Name: "ssa-destination", Namespace: "default"}
+
+		var reconciler *ConfigMapSyncReconciler
+
+		reconcileSync := func() *appsv2.ConfigMapSync {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: syncKey})
+			Expect(err).NotTo(HaveOccurred())
+			configMapSync := &appsv2.ConfigMapSync{}
+			Expect(k8sClient.Get(ctx, syncKey, configMapSync)).To(Succeed())
+			return configMapSync
+		}
+
+		setSource := func(value string) {
+			source := &corev1.ConfigMap{}
+			Expect(k8sClient.Get(ctx, sourceKey, source)).To(Succeed())
+			source.Data = map[string]string{"key": value}
+			Expect(k8sClient.Update(ctx, source)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			reconciler = &ConfigMapSyncReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: sourceKey.Name, Namespace: sourceKey.Namespace},
+				Data:       map[string]string{"key": "v1"},
+			})).To(Succeed())
+			Expect(k8sClient.Create(ctx, &appsv2.ConfigMapSync{
+				ObjectMeta: metav1.ObjectMeta{Name: syncKey.Name, Namespace: syncKey.Namespace},
+				Spec: appsv2.ConfigMapSyncSpec{
+					Source:       appsv2.SourceReference{Namespace: sourceKey.Namespace, Name: sourceKey.Name},
+					Destinations: []appsv2.DestinationReference{{Namespace: destinationKey.Namespace, Name: destinationKey.Name}},
+				},
+			})).To(Succeed())
+			reconcileSync()
+		})
+
+		AfterEach(func() {
+			configMapSync := &appsv2.ConfigMapSync{}
+			Expect(k8sClient.Get(ctx, syncKey, configMapSync)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, configMapSync)).To(Succeed())
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: syncKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Delete(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: sourceKey.Name, Namespace: sourceKey.Namespace},
+			})).To(Succeed())
+		})
+
+		It("should own only the synced fields and keep other tools' labels", func() {
+			destination := &corev1.ConfigMap{}
+			Expect(k8sClient.Get(ctx, destinationKey, destination)).To(Succeed())
+			managers := []string{}
+			for _, entry := range destination.ManagedFields {
+				managers = append(managers, entry.Manager)
+			}
+			Expect(managers).To(ConsistOf(FieldManager))
+
+			destination.Labels["team"] = "payments"
+			Expect(k8sClient.Update(ctx, destination, client.FieldOwner("other-tool"))).To(Succeed())
+
+			setSource("v2")
+			configMapSync := reconcileSync()
+			Expect(meta.IsStatusConditionTrue(configMapSync.Status.Conditions, TypeReady)).To(BeTrue())
+
+			Expect(k8sClient.Get(ctx, destinationKey, destination)).To(Succeed())
+			Expect(destination.Data).To(Equal(map[string]string{"key": "v2"}))
+			Expect(destination.Labels).To(HaveKeyWithValue("team", "payments"))
+		})
+
+		It("should report a conflict on a synced key and take it over with force", func() {
+			destination := &corev1.ConfigMap{}
+			Expect(k8sClient.Get(ctx, destinationKey, destination)).To(Succeed())
+			destination.Data["key"] = "edited"
+			Expect(k8sClient.Update(ctx, destination, client.FieldOwner("kubectl-edit"))).To(Succeed())
+
+			setSource("v2")
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: syncKey})
+			Expect(errors.IsConflict(err)).To(BeTrue())
+			configMapSync := &appsv2.ConfigMapSync{}
+			Expect(k8sClient.Get(ctx, syncKey, configMapSync)).To(Succeed())
+			stalled := meta.FindStatusCondition(configMapSync.Status.Conditions, TypeStalled)
+			Expect(stalled).NotTo(BeNil())
+			Expect(stalled.Reason).To(Equal("FieldConflict"))
+			Expect(k8sClient.Get(ctx, destinationKey, destination)).To(Succeed())
+			Expect(destination.Data).To(HaveKeyWithValue("key", "edited"))
+
+			configMapSync.Spec.Force = true
+			Expect(k8sClient.Update(ctx, configMapSync)).To(Succeed())
+			configMapSync = reconcileSync()
+			Expect(meta.IsStatusConditionTrue(configMapSync.Status.Conditions, TypeReady)).To(BeTrue())
+			Expect(k8sClient.Get(ctx, destinationKey, destination)).To(Succeed())
+			Expect(destination.Data).To(Equal(map[string]string{"key": "v2"}))
+		})
+	})
+
+	Context("When the sync is in a steady state", func() {
+		ctx := context.Background()
+		syncKey := types.NamespacedName{Name: "steady-sync", Namespace: "default"}
+		sourceKey := types.NamespacedName{Name: "steady-source", Namespace: "default"}
+
+		It("should make no API writes", func() {
+			writes := 0
+			reconciler := &ConfigMapSyncReconciler{
+				Client: countWrites(k8sClient.(client.WithWatch), &writes),
+				Scheme: k8sClient.Scheme(),
+			}
+			Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: sourceKey.Name, Namespace: sourceKey.Namespace},
+				Data:       map[string]string{"key": "value"},
+			})).To(Succeed())
+			Expect(k8sClient.Create(ctx, &appsv2.ConfigMapSync{
+				ObjectMeta: metav1.ObjectMeta{Name: syncKey.Name, Namespace: syncKey.Namespace},
+				Spec: appsv2.ConfigMapSyncSpec{
+					Source:       appsv2.SourceReference{Namespace: sourceKey.Namespace, Name: sourceKey.Name},
+					Destinations: []appsv2.DestinationReference{{Namespace: "default", Name: "steady-destination"}},
+				},
+			})).To(Succeed())
+
+			By("converging: finalizer, destination and status")
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: syncKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(writes).To(Equal(3))
+
+			By("reconciling again without changes")
+			writes = 0
+			for range 3 {
+				_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: syncKey})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(writes).To(BeZero())
+
+			configMapSync := &appsv2.ConfigMapSync{}
+			Expect(k8sClient.Get(ctx, syncKey, configMapSync)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, configMapSync)).To(Succeed())
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: syncKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Delete(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: sourceKey.Name, Namespace: sourceKey.Namespace},
+			})).To(Succeed())
+		})
+	})
+})
+
+var _ = Describe("ConfigMapSync watch predicates", func() {
+	configMapSync := func(generation int64, annotations map[string]string, retries int) *appsv2.ConfigMapSync {
+		return &appsv2.ConfigMapSync{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-sync", Generation: generation, Annotations: annotations},
+			Status:     appsv2.ConfigMapSyncStatus{RetryCount: retries},
+		}
+	}
+	update := func(oldObject, newObject *appsv2.ConfigMapSync) bool {
+		return syncChangedPredicate().Update(event.UpdateEvent{ObjectOld: oldObject, ObjectNew: newObject})
+	}
+
+	It("should ignore status-only updates", func() {
+		Expect(update(configMapSync(1, nil, 0), configMapSync(1, nil, 1))).To(BeFalse())
+	})
+
+	It("should pass spec and annotation changes", func() {
+		Expect(update(configMapSync(1, nil, 0), configMapSync(2, nil, 0))).To(BeTrue())
+		Expect(update(configMapSync(1, nil, 0), configMapSync(1, map[string]string{"example.com/note": "x"}, 0))).To(BeTrue())
+	})
 })
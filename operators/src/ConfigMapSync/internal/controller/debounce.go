@@ -0,0 +1,134 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// reasonDebouncing marks a source change waiting out spec.debounce.
+	reasonDebouncing = "Debouncing"
+	// reasonRateLimited marks a source change held by spec.maxRatePerHour.
+	reasonRateLimited = "RateLimited"
+
+	// propagationPeriod is the period spec.maxRatePerHour counts over.
+	propagationPeriod = time.Hour
+)
+
+// sourceChanged reports whether the source content differs from what was
+// last synced. The first sync of a ConfigMapSync is not a change.
+func sourceChanged(configMapSync *appsv2.ConfigMapSync, sourceHash string) bool {
+	synced := configMapSync.Status.Source.SyncedHash
+	return synced != "" && synced != sourceHash
+}
+
+// debounceChange holds a source change until the source stayed unchanged
+// for spec.debounce. Every newer version of the source restarts the quiet
+// period, so a burst of changes is propagated once. held reports whether
+// the reconcile ends here.
+func (r *ConfigMapSyncReconciler) debounceChange(ctx context.Context, configMapSync *appsv2.ConfigMapSync, originalStatus *appsv2.ConfigMapSyncStatus, sourceConfigMap *corev1.ConfigMap, sourceHash string) (ctrl.Result, bool, error) {
+	debounce := configMapSync.Spec.Debounce
+	if debounce == nil || debounce.Duration <= 0 || !sourceChanged(configMapSync, sourceHash) {
+		return ctrl.Result{}, false, nil
+	}
+
+	now := r.clock()
+	notBefore := now.Add(debounce.Duration)
+	if pending := configMapSync.Status.Pending; pending != nil && pending.SourceResourceVersion == sourceConfigMap.ResourceVersion {
+		// This version was seen before; a change held for another reason
+		// has already waited out the quiet period
+		if pending.Reason != reasonDebouncing || pending.NotBefore == nil || !now.Before(pending.NotBefore.Time) {
+			return ctrl.Result{}, false, nil
+		}
+		notBefore = pending.NotBefore.Time
+	}
+
+	log.FromContext(ctx).Info("Debouncing source change", "resourceVersion", sourceConfigMap.ResourceVersion, "notBefore", notBefore)
+	result, err := r.holdChange(ctx, configMapSync, originalStatus, &appsv2.PendingChange{
+		Reason:                reasonDebouncing,
+		Message:               "Waiting for the source to stay unchanged until " + notBefore.UTC().Format(time.RFC3339),
+		SourceResourceVersion: sourceConfigMap.ResourceVersion,
+		NotBefore:             &metav1.Time{Time: notBefore},
+	}, now)
+	return result, true, err
+}
+
+// recentPropagations returns the propagations of the past hour.
+func recentPropagations(configMapSync *appsv2.ConfigMapSync, now time.Time) []metav1.Time {
+	var recent []metav1.Time
+	for _, propagated := range configMapSync.Status.RecentPropagations {
+		if now.Sub(propagated.Time) < propagationPeriod {
+			recent = append(recent, propagated)
+		}
+	}
+	return recent
+}
+
+// limitRate holds a source change while spec.maxRatePerHour changes were
+// already propagated in the past hour, until the oldest of them is an hour
+// old. held reports whether the reconcile ends here.
+func (r *ConfigMapSyncReconciler) limitRate(ctx context.Context, configMapSync *appsv2.ConfigMapSync, originalStatus *appsv2.ConfigMapSyncStatus, sourceConfigMap *corev1.ConfigMap, sourceHash string) (ctrl.Result, bool, error) {
+	limit := int(configMapSync.Spec.MaxRatePerHour)
+	if limit <= 0 || !sourceChanged(configMapSync, sourceHash) {
+		return ctrl.Result{}, false, nil
+	}
+	now := r.clock()
+	recent := recentPropagations(configMapSync, now)
+	if len(recent) < limit {
+		return ctrl.Result{}, false, nil
+	}
+
+	notBefore := recent[len(recent)-limit].Add(propagationPeriod)
+	message := fmt.Sprintf("%d changes were propagated in the past hour, the limit is %d; the next one is due at %s",
+		len(recent), limit, notBefore.UTC().Format(time.RFC3339))
+	log.FromContext(ctx).Info("Source change rate limited", "resourceVersion", sourceConfigMap.ResourceVersion, "notBefore", notBefore)
+	if pending := configMapSync.Status.Pending; pending == nil || pending.Reason != reasonRateLimited {
+		r.recordEvent(configMapSync, nil, corev1.EventTypeWarning, EventReasonRateLimited, message)
+	}
+	result, err := r.holdChange(ctx, configMapSync, originalStatus, &appsv2.PendingChange{
+		Reason:                reasonRateLimited,
+		Message:               message,
+		SourceResourceVersion: sourceConfigMap.ResourceVersion,
+		NotBefore:             &metav1.Time{Time: notBefore},
+	}, now)
+	return result, true, err
+}
+
+// recordPropagation adds a propagated source change to the times counted
+// by spec.maxRatePerHour and drops those older than an hour.
+func (r *ConfigMapSyncReconciler) recordPropagation(configMapSync *appsv2.ConfigMapSync, propagated bool) {
+	if configMapSync.Spec.MaxRatePerHour <= 0 {
+		configMapSync.Status.RecentPropagations = nil
+		return
+	}
+	now := r.clock()
+	recent := recentPropagations(configMapSync, now)
+	if propagated {
+		recent = append(recent, metav1.NewTime(now))
+	}
+	configMapSync.Status.RecentPropagations = recent
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+var _ = Describe("Debounce and rate limits", func() {
+	ctx := context.Background()
+	syncKey := types.NamespacedName{Name: "app-sync", Namespace: "team-a"}
+	sourceKey := types.NamespacedName{Name: "app-config", Namespace: "team-a"}
+	destinationKey := types.NamespacedName{Name: "app-config", Namespace: "tenant-1"}
+	start := time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)
+
+	var (
+		reconciler *ConfigMapSyncReconciler
+		now        time.Time
+	)
+
+	setup := func(spec func(*appsv2.ConfigMapSyncSpec)) {
+		now = start
+		reconciler = newFakeReconciler(interceptor.Funcs{}, newTestSync(spec), newTestSource(map[string]string{"key": "v1"}))
+		reconciler.now = func() time.Time { return now }
+	}
+	changeSource := func(value string) {
+		source := &corev1.ConfigMap{}
+		Expect(reconciler.Get(ctx, sourceKey, source)).To(Succeed())
+		source.Data["key"] = value
+		Expect(reconciler.Update(ctx, source)).To(Succeed())
+	}
+
+	destinationValue := func() string {
+		destination := &corev1.ConfigMap{}
+		Expect(reconciler.Get(ctx, destinationKey, destination)).To(Succeed())
+		return destination.Data["key"]
+	}
+
+	reconcile := func() (ctrl.Result, *appsv2.ConfigMapSync) {
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+		Expect(err).NotTo(HaveOccurred())
+		configMapSync := &appsv2.ConfigMapSync{}
+		Expect(reconciler.Get(ctx, syncKey, configMapSync)).To(Succeed())
+		return result, configMapSync
+	}
+
+	It("should propagate a burst of source changes once after the quiet period", func() {
+		setup(func(spec *appsv2.ConfigMapSyncSpec) {
+			spec.Debounce = &metav1.Duration{Duration: 30 * time.Second}
+		})
+
+		// The first sync is not held
+		reconcile()
+		Expect(destinationValue()).To(Equal("v1"))
+
+		changeSource("v2")
+		result, configMapSync := reconcile()
+		Expect(result.RequeueAfter).To(Equal(30 * time.Second))
+		Expect(destinationValue()).To(Equal("v1"))
+		Expect(configMapSync.Status.Pending).To(HaveField("Reason", "Debouncing"))
+		Expect(configMapSync.Status.Pending.NotBefore.Time).To(BeTemporally("==", start.Add(30*time.Second)))
+
+		// Another change restarts the quiet period
+		now = start.Add(10 * time.Second)
+		changeSource("v3")
+		result, configMapSync = reconcile()
+		Expect(result.RequeueAfter).To(Equal(30 * time.Second))
+		Expect(configMapSync.Status.Pending.NotBefore.Time).To(BeTemporally("==", start.Add(40*time.Second)))
+		Expect(meta.FindStatusCondition(configMapSync.Status.Conditions, TypeReconciling)).To(HaveField("Reason", "Debouncing"))
+
+		// An early requeue keeps waiting for the same deadline
+		now = start.Add(20 * time.Second)
+		result, _ = reconcile()
+		Expect(result.RequeueAfter).To(Equal(20 * time.Second))
+		Expect(destinationValue()).To(Equal("v1"))
+
+		now = start.Add(40 * time.Second)
+		result, configMapSync = reconcile()
+		Expect(result.RequeueAfter).To(BeZero())
+		Expect(destinationValue()).To(Equal("v3"))
+		Expect(configMapSync.Status.Pending).To(BeNil())
+		Expect(meta.IsStatusConditionTrue(configMapSync.Status.Conditions, TypeReady)).To(BeTrue())
+	})
+
+	It("should hold changes beyond maxRatePerHour until the oldest propagation is an hour old", func() {
+		setup(func(spec *appsv2.ConfigMapSyncSpec) {
+			spec.MaxRatePerHour = 2
+		})
+
+		reconcile()
+		now = start.Add(time.Minute)
+		changeSource("v2")
+		_, configMapSync := reconcile()
+		Expect(destinationValue()).To(Equal("v2"))
+		Expect(configMapSync.Status.RecentPropagations).To(HaveLen(2))
+
+		now = start.Add(2 * time.Minute)
+		changeSource("v3")
+		result, configMapSync := reconcile()
+		Expect(result.RequeueAfter).To(Equal(58 * time.Minute))
+		Expect(destinationValue()).To(Equal("v2"))
+		Expect(configMapSync.Status.Pending).To(HaveField("Reason", "RateLimited"))
+		Expect(configMapSync.Status.Pending.NotBefore.Time).To(BeTemporally("==", start.Add(time.Hour)))
+
+		now = start.Add(time.Hour)
+		_, configMapSync = reconcile()
+		Expect(destinationValue()).To(Equal("v3"))
+		Expect(configMapSync.Status.Pending).To(BeNil())
+		Expect(configMapSync.Status.RecentPropagations).To(HaveLen(2))
+	})
+
+	It("should not count syncs that propagated no source change", func() {
+		setup(func(spec *appsv2.ConfigMapSyncSpec) {
+			spec.MaxRatePerHour = 1
+		})
+
+		reconcile()
+		now = start.Add(time.Minute)
+		_, configMapSync := reconcile()
+		Expect(configMapSync.Status.RecentPropagations).To(HaveLen(1))
+		Expect(configMapSync.Status.Pending).To(BeNil())
+	})
+})
@@ -0,0 +1,401 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/csaupgrade"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// FieldManager is the server-side apply field manager of destination writes.
+// It owns only the synced data keys and the sync labels and annotations.
+const FieldManager = "configmapsync-controller"
+
+// legacyFieldManagers wrote destinations with update requests before
+// server-side apply; "manager" is the binary name, which client-go sends as
+// the default field manager.
+var legacyFieldManagers = []string{"manager"}
+
+// sourceKey returns the source ConfigMap of a sync.
+func sourceKey(configMapSync *appsv2.ConfigMapSync) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: configMapSync.Spec.Source.Namespace,
+		Name:      configMapSync.Spec.Source.Name,
+	}
+}
+
+// destinationKeys returns the destination ConfigMaps of a sync in spec
+// order. Unset names default to the source name and duplicates are dropped.
+func destinationKeys(configMapSync *appsv2.ConfigMapSync) []types.NamespacedName {
+	keys := make([]types.NamespacedName, 0, len(configMapSync.Spec.Destinations))
+	seen := make(map[types.NamespacedName]bool, len(configMapSync.Spec.Destinations))
+	for _, destination := range configMapSync.Spec.Destinations {
+		key := types.NamespacedName{Namespace: destination.Namespace, Name: destination.Name}
+		if key.Name == "" {
+			key.Name = configMapSync.Spec.Source.Name
+		}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// legacySyncLabels are the sync labels written before content hashes were
+// versioned.
+var legacySyncLabels = []string{LabelSyncName, LabelSyncNamespace, LabelManagedBy}
+
+// syncLabels returns the labels that tie a destination to its sync.
+func syncLabels(configMapSync *appsv2.ConfigMapSync) map[string]string {
+	return map[string]string{
+		LabelSyncName:      configMapSync.Name,
+		LabelSyncNamespace: configMapSync.Namespace,
+		LabelManagedBy:     ManagedByValue,
+	}
+}
+
+// desiredDestination builds the destination ConfigMap for key, carrying the
+// sync labels and the hash of the content it was built from.
+func desiredDestination(configMapSync *appsv2.ConfigMapSync, key types.NamespacedName, syncData map[string]string, binaryData map[string][]byte, sourceHash string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+			Labels:    syncLabels(configMapSync),
+			Annotations: map[string]string{
+				SourceHashAnnotation:                        sourceHash,
+				"configmapsync.apps.kapendra.com/last-sync": time.Now().Format(time.RFC3339),
+			},
+		},
+		Data:       syncData, // Copy all data from source
+		BinaryData: binaryData,
+	}
+}
+
+// syncDestination applies one destination ConfigMap and reports its state.
+// An up-to-date destination is only written when resync is set. The
+// returned status is meaningful even when the write failed.
+func (r *ConfigMapSyncReconciler) syncDestination(ctx context.Context, configMapSync *appsv2.ConfigMapSync, sourceConfigMap, destinationConfigMap *corev1.ConfigMap, resync bool) (appsv2.DestinationStatus, error) {
+	logger := log.FromContext(ctx)
+	sourceKey := client.ObjectKeyFromObject(sourceConfigMap)
+	destinationKey := client.ObjectKeyFromObject(destinationConfigMap)
+	destinationStatus := previousDestinationStatus(configMapSync, destinationKey)
+	syncData := destinationConfigMap.Data
+
+	existingConfigMap := &corev1.ConfigMap{}
+	err := r.traceCall(ctx, "ConfigMap.GetDestination", destinationKey, func(ctx context.Context) error {
+		return r.Get(ctx, destinationKey, existingConfigMap)
+	})
+	exists := err == nil
+	if err != nil && !apierrors.IsNotFound(err) {
+		// Unexpected error occurred while fetching destination ConfigMap
+		logger.Error(err, "Failed to fetch destination ConfigMap", "destinationKey", destinationKey)
+		return destinationStatus, err
+	}
+	destinationStatus.Exists = exists
+
+	var previousData map[string]string
+	drifted, upToDate := false, false
+	if exists {
+		destinationStatus.ResourceVersion = existingConfigMap.ResourceVersion
+		upToDate = destinationUpToDate(existingConfigMap, destinationConfigMap, sourceConfigMap)
+		if upToDate && !resync {
+			// Destination ConfigMap already holds the current source data.
+			// The skip is only reported as an event when a new version of
+			// the source brought no change, so steady-state and periodic
+			// reconciles stay free of writes
+			logger.Info("Destination ConfigMap already up to date, skipping write", "destinationKey", destinationKey)
+			if configMapSync.Status.Source.ResourceVersion != sourceConfigMap.ResourceVersion {
+				r.recordEvent(configMapSync, existingConfigMap, corev1.EventTypeNormal, EventReasonSyncSkipped,
+					fmt.Sprintf("Destination ConfigMap %s already matches %s", destinationKey, sourceKey))
+			}
+			return destinationStatus, nil
+		}
+
+		// Destinations written before server-side apply are owned by the
+		// manager's update requests; hand those fields to the apply manager
+		// so the first apply does not conflict with our own earlier writes
+		if err := r.upgradeFieldOwnership(ctx, existingConfigMap); err != nil {
+			logger.Error(err, "Failed to migrate destination field ownership", "destinationKey", destinationKey)
+			return destinationStatus, err
+		}
+
+		// A destination whose data no longer matches an unchanged source was
+		// edited out of band; this apply repairs that drift
+		drifted = !upToDate && recordedHashCurrent(existingConfigMap, destinationConfigMap, sourceConfigMap)
+		previousData = appliedData(existingConfigMap)
+	}
+
+	// Apply only the synced keys and the sync labels and annotations. Fields
+	// set by other tools are left alone
+	logger.Info("Applying destination ConfigMap", "destinationKey", destinationKey, "exists", exists)
+	destinationConfigMap.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}
+	applyOptions := []client.PatchOption{client.FieldOwner(FieldManager)}
+	if configMapSync.Spec.Force {
+		applyOptions = append(applyOptions, client.ForceOwnership)
+	}
+	err = r.traceCall(ctx, "ConfigMap.ApplyDestination", destinationKey, func(ctx context.Context) error {
+		return r.Patch(ctx, destinationConfigMap, client.Apply, applyOptions...)
+	})
+	if err != nil {
+		if apierrors.IsConflict(err) {
+			r.recordEvent(configMapSync, existingConfigMap, corev1.EventTypeWarning, EventReasonConflict,
+				fmt.Sprintf("Destination ConfigMap %s has fields owned by another manager, set spec.force to take them over: %v", destinationKey, err))
+		}
+		logger.Error(err, "Failed to apply destination ConfigMap", "destinationKey", destinationKey)
+		return destinationStatus, err
+	}
+	bytesCopiedTotal.Add(float64(configMapSize(destinationConfigMap)))
+	destinationStatus.Exists = true
+	destinationStatus.ResourceVersion = destinationConfigMap.ResourceVersion
+	destinationStatus.LastChange = summarizeChange(previousData, syncData)
+
+	switch {
+	case !exists:
+		logger.Info("Destination ConfigMap created successfully", "destinationKey", destinationKey)
+		r.recordEvent(configMapSync, destinationConfigMap, corev1.EventTypeNormal, EventReasonSyncCreated,
+			fmt.Sprintf("Created destination ConfigMap %s from %s", destinationKey, sourceKey))
+	case upToDate:
+		logger.Info("Destination ConfigMap resynced on request", "destinationKey", destinationKey)
+		r.recordEvent(configMapSync, destinationConfigMap, corev1.EventTypeNormal, EventReasonSyncUpdated,
+			fmt.Sprintf("Resynced destination ConfigMap %s from %s on request", destinationKey, sourceKey))
+	case drifted:
+		driftCorrectionsTotal.Inc()
+		logger.Info("Repaired drift in destination ConfigMap", "destinationKey", destinationKey)
+		r.recordEvent(configMapSync, destinationConfigMap, corev1.EventTypeNormal, EventReasonDriftRepaired,
+			fmt.Sprintf("Restored destination ConfigMap %s to match %s after out-of-band changes", destinationKey, sourceKey))
+	default:
+		logger.Info("Destination ConfigMap updated successfully", "destinationKey", destinationKey)
+		r.recordEvent(configMapSync, destinationConfigMap, corev1.EventTypeNormal, EventReasonSyncUpdated,
+			fmt.Sprintf("Updated destination ConfigMap %s from %s", destinationKey, sourceKey))
+	}
+	return destinationStatus, nil
+}
+
+// upgradeFieldOwnership moves the fields that legacy update requests of the
+// controller own over to FieldManager. It is a no-op once migrated.
+func (r *ConfigMapSyncReconciler) upgradeFieldOwnership(ctx context.Context, configMap *corev1.ConfigMap) error {
+	patch, err := csaupgrade.UpgradeManagedFieldsPatch(configMap, sets.New(legacyFieldManagers...), FieldManager)
+	if err != nil || patch == nil {
+		return err
+	}
+	return r.traceCall(ctx, "ConfigMap.UpgradeDestinationOwnership", client.ObjectKeyFromObject(configMap), func(ctx context.Context) error {
+		return r.Patch(ctx, configMap, client.RawPatch(types.JSONPatchType, patch))
+	})
+}
+
+// appliedData returns the data keys of a destination that FieldManager
+// owns, which excludes keys other tools added. Objects without managed
+// fields are taken to be fully owned.
+func appliedData(configMap *corev1.ConfigMap) map[string]string {
+	var owned map[string]json.RawMessage
+	found := false
+	for _, entry := range configMap.ManagedFields {
+		if entry.Manager != FieldManager || entry.Operation != metav1.ManagedFieldsOperationApply || entry.FieldsV1 == nil {
+			continue
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			return configMap.Data
+		}
+		found = true
+		if raw, ok := fields["f:data"]; ok {
+			if err := json.Unmarshal(raw, &owned); err != nil {
+				return configMap.Data
+			}
+		}
+	}
+	if !found {
+		return configMap.Data
+	}
+	data := make(map[string]string, len(owned))
+	for field := range owned {
+		key := strings.TrimPrefix(field, "f:")
+		if value, ok := configMap.Data[key]; ok {
+			data[key] = value
+		}
+	}
+	return data
+}
+
+// destinationUpToDate reports whether the existing destination already holds
+// the desired data, binary data, sync labels and content hash, so that
+// applying it again would change nothing but the last-sync timestamp. Keys
+// added by other tools are ignored; keys dropped from the source change the
+// hash. A destination that still carries a legacy hash is only held to the
+// labels written before the upgrade, so labels added since do not rewrite
+// it until its content changes.
+func destinationUpToDate(existing, desired, source *corev1.ConfigMap) bool {
+	for key, value := range desired.Data {
+		if current, ok := existing.Data[key]; !ok || current != value {
+			return false
+		}
+	}
+	for key, value := range desired.BinaryData {
+		if current, ok := existing.BinaryData[key]; !ok || !bytes.Equal(current, value) {
+			return false
+		}
+	}
+	legacy := !strings.HasPrefix(existing.Annotations[SourceHashAnnotation], contentHashPrefix)
+	for key, value := range desired.Labels {
+		if legacy && !slices.Contains(legacySyncLabels, key) {
+			continue
+		}
+		if existing.Labels[key] != value {
+			return false
+		}
+	}
+	return recordedHashCurrent(existing, desired, source)
+}
+
+// previousDestinationStatus returns the last reported status of a
+// destination, or an empty entry for a new one.
+func previousDestinationStatus(configMapSync *appsv2.ConfigMapSync, key types.NamespacedName) appsv2.DestinationStatus {
+	for _, destination := range configMapSync.Status.Destinations {
+		if destination.Namespace == key.Namespace && destination.Name == key.Name {
+			return *destination.DeepCopy()
+		}
+	}
+	return appsv2.DestinationStatus{Namespace: key.Namespace, Name: key.Name}
+}
+
+// managedDestinationKeys returns every destination the sync may have
+// written: those recorded in status.managedObjects, followed by those in
+// the spec that are not recorded yet.
+func managedDestinationKeys(configMapSync *appsv2.ConfigMapSync) []types.NamespacedName {
+	var keys []types.NamespacedName
+	for _, object := range configMapSync.Status.ManagedObjects {
+		keys = append(keys, types.NamespacedName{Namespace: object.Namespace, Name: object.Name})
+	}
+	for _, key := range destinationKeys(configMapSync) {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// addManagedObject records a written destination in status.managedObjects,
+// which is kept sorted so that it compares stably.
+func addManagedObject(configMapSync *appsv2.ConfigMapSync, key types.NamespacedName) {
+	object := appsv2.ManagedObject{Namespace: key.Namespace, Name: key.Name}
+	index, found := slices.BinarySearchFunc(configMapSync.Status.ManagedObjects, object, compareManagedObjects)
+	if !found {
+		configMapSync.Status.ManagedObjects = slices.Insert(configMapSync.Status.ManagedObjects, index, object)
+	}
+}
+
+func compareManagedObjects(a, b appsv2.ManagedObject) int {
+	return cmp.Or(strings.Compare(a.Namespace, b.Namespace), strings.Compare(a.Name, b.Name))
+}
+
+// pruneDestinations cleans up the managed objects that are no longer in the
+// spec. Objects that could not be cleaned up stay recorded and are retried.
+func (r *ConfigMapSyncReconciler) pruneDestinations(ctx context.Context, configMapSync *appsv2.ConfigMapSync) error {
+	desired := destinationKeys(configMapSync)
+	var errs []error
+	kept := configMapSync.Status.ManagedObjects[:0]
+	for _, object := range configMapSync.Status.ManagedObjects {
+		key := types.NamespacedName{Namespace: object.Namespace, Name: object.Name}
+		if !slices.Contains(desired, key) {
+			if err := r.cleanupDestination(ctx, configMapSync, key); err != nil {
+				errs = append(errs, fmt.Errorf("cleaning up destination ConfigMap %s: %w", key, err))
+			} else {
+				continue
+			}
+		}
+		kept = append(kept, object)
+	}
+	configMapSync.Status.ManagedObjects = kept
+	if len(kept) == 0 {
+		configMapSync.Status.ManagedObjects = nil
+	}
+	return errors.Join(errs...)
+}
+
+// cleanupDestination removes a destination that the sync no longer manages
+// according to its deletion policy: Delete deletes it and Orphan strips the
+// sync labels so it is left alone from then on. A destination that is gone
+// or has been taken over by another ConfigMapSync is not touched.
+func (r *ConfigMapSyncReconciler) cleanupDestination(ctx context.Context, configMapSync *appsv2.ConfigMapSync, key types.NamespacedName) error {
+	logger := log.FromContext(ctx)
+	destination := &corev1.ConfigMap{}
+	err := r.traceCall(ctx, "ConfigMap.GetDestination", key, func(ctx context.Context) error {
+		return r.Get(ctx, key, destination)
+	})
+	if apierrors.IsNotFound(err) {
+		logger.Info("Destination ConfigMap not found, skipping cleanup", "destinationKey", key)
+		r.recordEvent(configMapSync, nil, corev1.EventTypeNormal, EventReasonCleanupCompleted,
+			fmt.Sprintf("Destination ConfigMap %s already absent", key))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if destination.Labels[LabelSyncName] != configMapSync.Name || destination.Labels[LabelSyncNamespace] != configMapSync.Namespace {
+		logger.Info("Destination ConfigMap is no longer managed by this ConfigMapSync, skipping cleanup", "destinationKey", key)
+		return nil
+	}
+
+	if configMapSync.Spec.DeletionPolicy == appsv2.DeletionPolicyOrphan {
+		patch := client.MergeFrom(destination.DeepCopy())
+		for label := range syncLabels(configMapSync) {
+			delete(destination.Labels, label)
+		}
+		err := r.traceCall(ctx, "ConfigMap.OrphanDestination", key, func(ctx context.Context) error {
+			return r.Patch(ctx, destination, patch)
+		})
+		if err != nil {
+			return err
+		}
+		logger.Info("Destination ConfigMap orphaned", "destinationKey", key)
+		r.recordEvent(configMapSync, destination, corev1.EventTypeNormal, EventReasonCleanupCompleted,
+			fmt.Sprintf("Left destination ConfigMap %s in place, it is no longer synced", key))
+		return nil
+	}
+
+	logger.Info("Destination ConfigMap found and deleting it", "destinationKey", key)
+	err = r.traceCall(ctx, "ConfigMap.DeleteDestination", key, func(ctx context.Context) error {
+		return r.Delete(ctx, destination, client.Preconditions{UID: &destination.UID})
+	})
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	logger.Info("Destination ConfigMap deleted successfully", "destinationKey", key)
+	r.recordEvent(configMapSync, nil, corev1.EventTypeNormal, EventReasonCleanupCompleted,
+		fmt.Sprintf("Deleted destination ConfigMap %s", key))
+	return nil
+}
@@ -0,0 +1,331 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+var _ = Describe("Sync destinations", func() {
+	ctx := context.Background()
+	syncKey := types.NamespacedName{Name: "app-sync", Namespace: "team-a"}
+
+	It("should default destination names to the source name and drop duplicates", func() {
+		configMapSync := &appsv2.ConfigMapSync{Spec: appsv2.ConfigMapSyncSpec{
+			Source: appsv2.SourceReference{Namespace: "team-a", Name: "app-config"},
+			Destinations: []appsv2.DestinationReference{
+				{Namespace: "tenant-1"},
+				{Namespace: "tenant-2", Name: "renamed"},
+				{Namespace: "tenant-1", Name: "app-config"},
+			},
+		}}
+		Expect(destinationKeys(configMapSync)).To(Equal([]types.NamespacedName{
+			{Namespace: "tenant-1", Name: "app-config"},
+			{Namespace: "tenant-2", Name: "renamed"},
+		}))
+	})
+
+	It("should sync every destination and keep going when one fails", func() {
+		configMapSync := newTestSync(func(spec *appsv2.ConfigMapSyncSpec) {
+			spec.Destinations = []appsv2.DestinationReference{
+				{Namespace: "tenant-1"},
+				{Namespace: "locked"},
+				{Namespace: "tenant-2", Name: "renamed"},
+			}
+		})
+		source := newTestSource(map[string]string{"key": "value"})
+		reconciler := newFakeReconciler(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				if obj.GetNamespace() == "locked" {
+					return fmt.Errorf("injected apply failure")
+				}
+				return emulateApply(ctx, c, obj, patch, opts...)
+			},
+		}, configMapSync, source)
+
+		// The error hands the retry to the workqueue's backoff
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+		Expect(err).To(MatchError(ContainSubstring("locked/app-config")))
+
+		for _, key := range []types.NamespacedName{
+			{Namespace: "tenant-1", Name: "app-config"},
+			{Namespace: "tenant-2", Name: "renamed"},
+		} {
+			destination := &corev1.ConfigMap{}
+			Expect(reconciler.Get(ctx, key, destination)).To(Succeed())
+			Expect(destination.Data).To(Equal(source.Data))
+		}
+
+		Expect(reconciler.Get(ctx, syncKey, configMapSync)).To(Succeed())
+		Expect(configMapSync.Status.SyncStatus).To(Equal(appsv2.SyncFailed))
+		Expect(configMapSync.Status.Message).To(ContainSubstring("locked/app-config"))
+		Expect(meta.IsStatusConditionTrue(configMapSync.Status.Conditions, TypeReconciling)).To(BeTrue())
+		Expect(configMapSync.Status.Destinations).To(HaveLen(3))
+		Expect(configMapSync.Status.Destinations[0].Exists).To(BeTrue())
+		Expect(configMapSync.Status.Destinations[1].Exists).To(BeFalse())
+		Expect(configMapSync.Status.Destinations[2]).To(HaveField("Name", "renamed"))
+	})
+
+	Context("with server-side apply", func() {
+		var (
+			configMapSync *appsv2.ConfigMapSync
+			source        *corev1.ConfigMap
+			destination   *corev1.ConfigMap
+			recorder      *record.FakeRecorder
+			reconciler    *ConfigMapSyncReconciler
+			applies       []client.PatchOptions
+			conflict      bool
+		)
+
+		BeforeEach(func() {
+			configMapSync = newTestSync(nil)
+			source = newTestSource(map[string]string{"key": "new"})
+			// A destination synced before, since annotated by another tool
+			destination = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "app-config",
+					Namespace:   "tenant-1",
+					Labels:      map[string]string{"team": "payments"},
+					Annotations: map[string]string{"backup.example.com/policy": "daily"},
+				},
+				Data: map[string]string{"key": "old"},
+			}
+			applies = nil
+			conflict = false
+			recorder = record.NewFakeRecorder(20)
+			reconciler = newFakeReconciler(interceptor.Funcs{
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+					if patch.Type() == types.ApplyPatchType {
+						options := client.PatchOptions{}
+						options.ApplyOptions(opts)
+						applies = append(applies, options)
+						if conflict && (options.Force == nil || !*options.Force) {
+							return apierrors.NewConflict(corev1.Resource("configmaps"), obj.GetName(),
+								fmt.Errorf(`Apply failed with 1 conflict: conflict with "kubectl-edit": .data.key`))
+						}
+					}
+					return emulateApply(ctx, c, obj, patch, opts...)
+				},
+			}, configMapSync, source, destination)
+			reconciler.Recorder = recorder
+		})
+
+		It("should apply as the controller's field manager and keep other tools' metadata", func() {
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(applies).To(HaveLen(1))
+			Expect(applies[0].FieldManager).To(Equal(FieldManager))
+			Expect(applies[0].Force).To(BeNil())
+
+			Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(destination), destination)).To(Succeed())
+			Expect(destination.Data).To(Equal(source.Data))
+			Expect(destination.Labels).To(HaveKeyWithValue("team", "payments"))
+			Expect(destination.Labels).To(HaveKeyWithValue(LabelManagedBy, ManagedByValue))
+			Expect(destination.Annotations).To(HaveKeyWithValue("backup.example.com/policy", "daily"))
+		})
+
+		It("should only report a skipped write for a new version of the source", func() {
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonSyncUpdated)))
+			for len(recorder.Events) > 0 {
+				<-recorder.Events
+			}
+
+			// An unchanged source emits nothing
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.Events).NotTo(Receive())
+
+			// A new source version with the same content is skipped visibly
+			Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(source), source)).To(Succeed())
+			source.Annotations = map[string]string{"example.com/touched": "true"}
+			Expect(reconciler.Update(ctx, source)).To(Succeed())
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(applies).To(HaveLen(1))
+			Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonSyncSkipped)))
+		})
+
+		It("should report a field ownership conflict instead of overwriting", func() {
+			conflict = true
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+			Expect(apierrors.IsConflict(err)).To(BeTrue())
+
+			Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(destination), destination)).To(Succeed())
+			Expect(destination.Data).To(HaveKeyWithValue("key", "old"))
+
+			Expect(reconciler.Get(ctx, syncKey, configMapSync)).To(Succeed())
+			stalled := meta.FindStatusCondition(configMapSync.Status.Conditions, TypeStalled)
+			Expect(stalled).NotTo(BeNil())
+			Expect(stalled.Reason).To(Equal("FieldConflict"))
+			Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonConflict)))
+		})
+
+		It("should take over conflicting fields when force is set", func() {
+			conflict = true
+			configMapSync.Spec.Force = true
+			Expect(reconciler.Update(ctx, configMapSync)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(applies).To(HaveLen(1))
+			Expect(applies[0].Force).To(HaveValue(BeTrue()))
+			Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(destination), destination)).To(Succeed())
+			Expect(destination.Data).To(Equal(source.Data))
+		})
+	})
+
+	It("should only count data keys owned by the apply manager as previously synced", func() {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				ManagedFields: []metav1.ManagedFieldsEntry{
+					{
+						Manager:   FieldManager,
+						Operation: metav1.ManagedFieldsOperationApply,
+						FieldsV1:  &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:synced":{}}}`)},
+					},
+					{
+						Manager:   "kubectl-edit",
+						Operation: metav1.ManagedFieldsOperationUpdate,
+						FieldsV1:  &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:local":{}}}`)},
+					},
+				},
+			},
+			Data: map[string]string{"synced": "a", "local": "b"},
+		}
+		Expect(appliedData(configMap)).To(Equal(map[string]string{"synced": "a"}))
+
+		configMap.ManagedFields = nil
+		Expect(appliedData(configMap)).To(Equal(configMap.Data))
+	})
+
+	It("should ignore keys added by other tools when checking for changes", func() {
+		hash := map[string]string{SourceHashAnnotation: contentHash(map[string]string{"key": "value"}, nil, nil)}
+		source := &corev1.ConfigMap{Data: map[string]string{"key": "value"}}
+		desired := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Annotations: hash}, Data: map[string]string{"key": "value"}}
+		existing := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Annotations: hash},
+			Data:       map[string]string{"key": "value", "local": "extra"},
+		}
+		Expect(destinationUpToDate(existing, desired, source)).To(BeTrue())
+
+		existing.Data["key"] = "edited"
+		Expect(destinationUpToDate(existing, desired, source)).To(BeFalse())
+	})
+
+	Context("when destinations are dropped from the spec", func() {
+		var (
+			configMapSync *appsv2.ConfigMapSync
+			reconciler    *ConfigMapSyncReconciler
+		)
+		oldKey := types.NamespacedName{Namespace: "tenant-1", Name: "app-config"}
+		newKey := types.NamespacedName{Namespace: "tenant-2", Name: "app-config"}
+
+		BeforeEach(func() {
+			configMapSync = newTestSync(nil)
+			reconciler = newFakeReconciler(interceptor.Funcs{}, configMapSync)
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciler.Get(ctx, syncKey, configMapSync)).To(Succeed())
+			Expect(configMapSync.Status.ManagedObjects).To(Equal([]appsv2.ManagedObject{{Namespace: "tenant-1", Name: "app-config"}}))
+		})
+
+		moveDestination := func(policy appsv2.DeletionPolicy) {
+			configMapSync.Spec.Destinations = []appsv2.DestinationReference{{Namespace: "tenant-2"}}
+			configMapSync.Spec.DeletionPolicy = policy
+			Expect(reconciler.Update(ctx, configMapSync)).To(Succeed())
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciler.Get(ctx, syncKey, configMapSync)).To(Succeed())
+			Expect(reconciler.Get(ctx, newKey, &corev1.ConfigMap{})).To(Succeed())
+			Expect(configMapSync.Status.ManagedObjects).To(Equal([]appsv2.ManagedObject{{Namespace: "tenant-2", Name: "app-config"}}))
+		}
+
+		It("should delete the old destination after the new one is synced", func() {
+			moveDestination("")
+
+			err := reconciler.Get(ctx, oldKey, &corev1.ConfigMap{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should leave the old destination in place without sync labels with the Orphan policy", func() {
+			moveDestination(appsv2.DeletionPolicyOrphan)
+
+			destination := &corev1.ConfigMap{}
+			Expect(reconciler.Get(ctx, oldKey, destination)).To(Succeed())
+			Expect(destination.Data).To(HaveKeyWithValue("key", "value"))
+			Expect(destination.Labels).NotTo(HaveKey(LabelManagedBy))
+			Expect(destination.Labels).NotTo(HaveKey(LabelSyncName))
+		})
+
+		It("should swap a destination of a sync at its destination limit", func() {
+			reconciler.Quotas = QuotaLimits{MaxDestinationsPerSync: 1}
+			moveDestination("")
+
+			err := reconciler.Get(ctx, oldKey, &corev1.ConfigMap{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			Expect(meta.IsStatusConditionTrue(configMapSync.Status.Conditions, TypeReady)).To(BeTrue())
+		})
+
+		It("should not touch a dropped destination that another ConfigMapSync has taken over", func() {
+			destination := &corev1.ConfigMap{}
+			Expect(reconciler.Get(ctx, oldKey, destination)).To(Succeed())
+			destination.Labels[LabelSyncName] = "other-sync"
+			Expect(reconciler.Update(ctx, destination)).To(Succeed())
+
+			moveDestination("")
+
+			Expect(reconciler.Get(ctx, oldKey, destination)).To(Succeed())
+		})
+
+		It("should clean up every managed destination when the ConfigMapSync is deleted", func() {
+			// Drop the old destination from the spec without reconciling, so
+			// only status still knows about it
+			configMapSync.Spec.Destinations = []appsv2.DestinationReference{{Namespace: "tenant-2"}}
+			Expect(reconciler.Update(ctx, configMapSync)).To(Succeed())
+			Expect(reconciler.Delete(ctx, configMapSync)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = reconciler.Get(ctx, oldKey, &corev1.ConfigMap{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			err = reconciler.Get(ctx, syncKey, &appsv2.ConfigMapSync{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,68 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Event reasons emitted on the ConfigMapSync and, where one exists, on the
+// destination ConfigMap.
+const (
+	EventReasonSyncCreated      = "SyncCreated"
+	EventReasonSyncUpdated      = "SyncUpdated"
+	EventReasonSyncSkipped      = "SyncSkipped"
+	EventReasonSourceMissing    = "SourceMissing"
+	EventReasonConflict         = "Conflict"
+	EventReasonDriftRepaired    = "DriftRepaired"
+	EventReasonCleanupCompleted = "CleanupCompleted"
+	EventReasonSyncBlocked      = "SyncBlocked"
+	// EventReasonOrphaned and EventReasonOrphanDeleted are emitted on
+	// destinations whose ConfigMapSync is gone or no longer targets them.
+	EventReasonOrphaned      = "Orphaned"
+	EventReasonOrphanDeleted = "OrphanDeleted"
+	// EventReasonPendingWindow is emitted when a change is held by a sync
+	// window or freeze, and EventReasonWindowOverridden when the override
+	// annotation lets it through anyway.
+	EventReasonPendingWindow    = "PendingWindow"
+	EventReasonWindowOverridden = "SyncWindowOverridden"
+	// EventReasonRateLimited is emitted when spec.maxRatePerHour holds a change.
+	EventReasonRateLimited = "RateLimited"
+	// EventReasonRetriesExhausted is emitted when spec.retryPolicy.maxAttempts
+	// consecutive failures stop the retries.
+	EventReasonRetriesExhausted = "RetriesExhausted"
+)
+
+// recordEvent emits an event on the ConfigMapSync and repeats it on the
+// destination ConfigMap, if given, so that tenants without access to the
+// ConfigMapSync can see where their data came from.
+func (r *ConfigMapSyncReconciler) recordEvent(configMapSync *appsv2.ConfigMapSync, destination *corev1.ConfigMap, eventType, reason, message string) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Event(configMapSync, eventType, reason, message)
+	if destination != nil && destination.UID != "" {
+		r.Recorder.Event(destination, eventType, reason,
+			fmt.Sprintf("%s (source %s/%s, ConfigMapSync %s/%s)", message,
+				configMapSync.Spec.Source.Namespace, configMapSync.Spec.Source.Name,
+				configMapSync.Namespace, configMapSync.Name))
+	}
+}
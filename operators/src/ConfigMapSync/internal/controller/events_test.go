@@ -0,0 +1,82 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+var _ = Describe("Sync events", func() {
+	configMapSync := &appsv2.ConfigMapSync{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-sync", Namespace: "team-a"},
+		Spec: appsv2.ConfigMapSyncSpec{
+			Source:       appsv2.SourceReference{Namespace: "team-a", Name: "app-config"},
+			Destinations: []appsv2.DestinationReference{{Namespace: "tenant-1"}},
+		},
+	}
+
+	It("should repeat events on an existing destination with provenance", func() {
+		recorder := record.NewFakeRecorder(10)
+		reconciler := &ConfigMapSyncReconciler{Recorder: recorder}
+		destination := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Name: "app-config", Namespace: "tenant-1", UID: "destination-uid",
+		}}
+
+		reconciler.recordEvent(configMapSync, destination, corev1.EventTypeNormal, EventReasonSyncUpdated, "Updated")
+
+		Expect(recorder.Events).To(Receive(Equal("Normal SyncUpdated Updated")))
+		Expect(recorder.Events).To(Receive(Equal(
+			"Normal SyncUpdated Updated (source team-a/app-config, ConfigMapSync team-a/app-sync)")))
+	})
+
+	It("should only emit on the ConfigMapSync when the destination was not persisted", func() {
+		recorder := record.NewFakeRecorder(10)
+		reconciler := &ConfigMapSyncReconciler{Recorder: recorder}
+
+		reconciler.recordEvent(configMapSync, &corev1.ConfigMap{}, corev1.EventTypeWarning, EventReasonSourceMissing, "gone")
+
+		Expect(recorder.Events).To(Receive(Equal("Warning SourceMissing gone")))
+		Expect(recorder.Events).NotTo(Receive())
+	})
+
+	It("should tolerate a reconciler without a recorder", func() {
+		reconciler := &ConfigMapSyncReconciler{}
+		Expect(func() {
+			reconciler.recordEvent(configMapSync, nil, corev1.EventTypeNormal, EventReasonSyncSkipped, "noop")
+		}).NotTo(Panic())
+	})
+
+	It("should treat a destination with matching data, labels and hash as up to date", func() {
+		desired := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Labels:      map[string]string{LabelManagedBy: ManagedByValue},
+			Annotations: map[string]string{SourceHashAnnotation: contentHash(map[string]string{"key": "value"}, nil, nil)},
+		}, Data: map[string]string{"key": "value"}}
+		existing := desired.DeepCopy()
+		existing.Labels["team"] = "a"
+		Expect(destinationUpToDate(existing, desired, desired)).To(BeTrue())
+
+		existing.Data["key"] = "changed"
+		Expect(destinationUpToDate(existing, desired, desired)).To(BeFalse())
+	})
+})
@@ -0,0 +1,182 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+// The fake-client specs share one fixture: the ConfigMapSync
+// team-a/app-sync syncs the source ConfigMap team-a/app-config to tenant-1.
+var (
+	testSyncKey   = types.NamespacedName{Name: "app-sync", Namespace: "team-a"}
+	testSourceKey = types.NamespacedName{Name: "app-config", Namespace: "team-a"}
+)
+
+// newTestScheme returns a scheme with the built-in and ConfigMapSync types.
+func newTestScheme() *runtime.Scheme {
+	testScheme := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(testScheme)).To(Succeed())
+	Expect(appsv2.AddToScheme(testScheme)).To(Succeed())
+	return testScheme
+}
+
+// newTestSync returns the fixture's ConfigMapSync with spec changed by
+// mutate, if set. It already carries the finalizer, so that a reconcile goes
+// straight to the sync.
+func newTestSync(mutate func(*appsv2.ConfigMapSyncSpec)) *appsv2.ConfigMapSync {
+	configMapSync := &appsv2.ConfigMapSync{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       testSyncKey.Name,
+			Namespace:  testSyncKey.Namespace,
+			Finalizers: []string{ConfigMapSyncFinalizer},
+		},
+		Spec: appsv2.ConfigMapSyncSpec{
+			Source:       appsv2.SourceReference{Namespace: testSourceKey.Namespace, Name: testSourceKey.Name},
+			Destinations: []appsv2.DestinationReference{{Namespace: "tenant-1"}},
+		},
+	}
+	if mutate != nil {
+		mutate(&configMapSync.Spec)
+	}
+	return configMapSync
+}
+
+// newTestSource returns the fixture's source ConfigMap holding data.
+func newTestSource(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: testSourceKey.Name, Namespace: testSourceKey.Namespace},
+		Data:       data,
+	}
+}
+
+// newFakeReconciler returns a reconciler on a fake client holding objs and,
+// unless objs replaces them with objects of the same kind and key, the
+// fixture's ConfigMapSync and a source holding key=value. Calls go through
+// funcs first; server-side apply is emulated by emulateApply unless funcs
+// sets Patch.
+func newFakeReconciler(funcs interceptor.Funcs, objs ...client.Object) *ConfigMapSyncReconciler {
+	for _, fixture := range []client.Object{newTestSync(nil), newTestSource(map[string]string{"key": "value"})} {
+		if !containsKeyOf(objs, fixture) {
+			objs = append(objs, fixture)
+		}
+	}
+	if funcs.Patch == nil {
+		funcs.Patch = emulateApply
+	}
+	testScheme := newTestScheme()
+	return &ConfigMapSyncReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(testScheme).
+			WithObjects(objs...).
+			WithStatusSubresource(&appsv2.ConfigMapSync{}).
+			WithInterceptorFuncs(funcs).
+			Build(),
+		Scheme: testScheme,
+	}
+}
+
+// containsKeyOf reports whether objs holds an object of the kind and key
+// of obj.
+func containsKeyOf(objs []client.Object, obj client.Object) bool {
+	for _, candidate := range objs {
+		if reflect.TypeOf(candidate) == reflect.TypeOf(obj) && client.ObjectKeyFromObject(candidate) == client.ObjectKeyFromObject(obj) {
+			return true
+		}
+	}
+	return false
+}
+
+// emulateApply serves server-side apply patches, which the fake client
+// rejects, as a create or a merge patch. Field ownership is not tracked; the
+// envtest specs cover it against a real API server.
+func emulateApply(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return c.Patch(ctx, obj, patch, opts...)
+	}
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	existing := obj.DeepCopyObject().(client.Object)
+	if err := c.Get(ctx, client.ObjectKeyFromObject(obj), existing); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		return c.Create(ctx, obj)
+	}
+	return c.Patch(ctx, obj, client.RawPatch(types.MergePatchType, data))
+}
+
+// interceptApply wraps c so that applying a ConfigMap fails.
+func interceptApply(c client.WithWatch) client.WithWatch {
+	return interceptor.NewClient(c, interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if _, ok := obj.(*corev1.ConfigMap); ok && patch.Type() == types.ApplyPatchType {
+				return fmt.Errorf("injected apply failure")
+			}
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+	})
+}
+
+// countWrites wraps c so that every create, update, patch and delete,
+// including status writes, increments writes.
+func countWrites(c client.WithWatch, writes *int) client.WithWatch {
+	return interceptor.NewClient(c, interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			*writes++
+			return c.Create(ctx, obj, opts...)
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			*writes++
+			return c.Update(ctx, obj, opts...)
+		},
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			*writes++
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			*writes++
+			return c.Delete(ctx, obj, opts...)
+		},
+		SubResourceUpdate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+			*writes++
+			return c.SubResource(subResourceName).Update(ctx, obj, opts...)
+		},
+		SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+			*writes++
+			return c.SubResource(subResourceName).Patch(ctx, obj, patch, opts...)
+		},
+	})
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SourceHashAnnotation records on each destination the hash of the
+	// content it was last synced with.
+	SourceHashAnnotation = "configmapsync.apps.kapendra.com/source-hash"
+
+	// contentHashPrefix versions the hash format. Unprefixed values are
+	// legacy hashes of the source data's fmt representation.
+	contentHashPrefix = "sha256-v2:"
+
+	// contentHashHeader starts the canonical encoding that is hashed.
+	contentHashHeader = "configmapsync-content-v2\n"
+)
+
+// contentHash hashes everything the controller writes to a destination
+// besides its name: the synced data after any decryption, the binary data
+// and the sync labels. Each part uses the length-prefixed encoding of the
+// signature payload over sorted keys, so different content never shares an
+// encoding.
+func contentHash(data map[string]string, binaryData map[string][]byte, labels map[string]string) string {
+	var buf bytes.Buffer
+	buf.WriteString(contentHashHeader)
+	writeStringMap(&buf, data)
+	writeBytesMap(&buf, binaryData)
+	writeStringMap(&buf, labels)
+	sum := sha256.Sum256(buf.Bytes())
+	return contentHashPrefix + hex.EncodeToString(sum[:])
+}
+
+// legacySourceHash is the hash destinations were annotated with before
+// contentHash. It is only used to recognise those destinations as current.
+func legacySourceHash(sourceData map[string]string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("%v", sourceData))))
+}
+
+// recordedHashCurrent reports whether the hash recorded on an existing
+// destination matches the desired content. Destinations synced before the
+// versioned format carry a legacy hash of the source data, which is
+// compared as such so that upgrading does not rewrite every destination.
+func recordedHashCurrent(existing, desired, source *corev1.ConfigMap) bool {
+	recorded := existing.Annotations[SourceHashAnnotation]
+	if strings.HasPrefix(recorded, contentHashPrefix) {
+		return recorded == desired.Annotations[SourceHashAnnotation]
+	}
+	return recorded != "" && recorded == legacySourceHash(source.Data)
+}
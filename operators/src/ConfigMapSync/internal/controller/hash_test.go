@@ -0,0 +1,109 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("Content hashing", func() {
+	It("should tell apart maps whose fmt representations collide", func() {
+		joined := map[string]string{"a": "b c:d"}
+		split := map[string]string{"a": "b", "c": "d"}
+		Expect(legacySourceHash(joined)).To(Equal(legacySourceHash(split)))
+		Expect(contentHash(joined, nil, nil)).NotTo(Equal(contentHash(split, nil, nil)))
+	})
+
+	It("should be versioned and cover binary data and labels", func() {
+		data := map[string]string{"key": "value"}
+		hash := contentHash(data, nil, nil)
+		Expect(hash).To(HavePrefix("sha256-v2:"))
+		Expect(strings.TrimPrefix(hash, "sha256-v2:")).To(HaveLen(64))
+		Expect(contentHash(map[string]string{"key": "value"}, nil, nil)).To(Equal(hash))
+
+		Expect(contentHash(data, map[string][]byte{"blob": {0x1}}, nil)).NotTo(Equal(hash))
+		Expect(contentHash(data, nil, map[string]string{LabelSyncName: "app-sync"})).NotTo(Equal(hash))
+		// A key moved between data and labels is still a different encoding
+		Expect(contentHash(nil, nil, data)).NotTo(Equal(hash))
+	})
+
+	It("should accept a legacy hash only when it matches the source data", func() {
+		source := &corev1.ConfigMap{Data: map[string]string{"key": "value"}}
+		desired := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			SourceHashAnnotation: contentHash(source.Data, nil, nil),
+		}}}
+		existing := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			SourceHashAnnotation: legacySourceHash(source.Data),
+		}}}
+		Expect(recordedHashCurrent(existing, desired, source)).To(BeTrue())
+
+		existing.Annotations[SourceHashAnnotation] = legacySourceHash(map[string]string{"key": "old"})
+		Expect(recordedHashCurrent(existing, desired, source)).To(BeFalse())
+
+		existing.Annotations[SourceHashAnnotation] = desired.Annotations[SourceHashAnnotation]
+		Expect(recordedHashCurrent(existing, desired, source)).To(BeTrue())
+	})
+
+	It("should not rewrite destinations synced with a legacy hash", func() {
+		source := newTestSource(map[string]string{"key": "value"})
+		// Exactly what the controller wrote before the upgrade
+		destination := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "app-config",
+				Namespace: "tenant-1",
+				Labels: map[string]string{
+					"configmapsync.apps.kapendra.com/sync-name":      "app-sync",
+					"configmapsync.apps.kapendra.com/sync-namespace": "team-a",
+					"configmapsync.apps.kapendra.com/managed-by":     "configmapsync-controller",
+				},
+				Annotations: map[string]string{SourceHashAnnotation: legacySourceHash(source.Data)},
+			},
+			Data: source.Data,
+		}
+		applies := 0
+		reconciler := newFakeReconciler(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				applies++
+				return emulateApply(ctx, c, obj, patch, opts...)
+			},
+		}, source, destination)
+
+		ctx := context.Background()
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: testSyncKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(applies).To(BeZero())
+
+		// The next real change moves the destination to the new format
+		source.Data = map[string]string{"key": "changed"}
+		Expect(reconciler.Update(ctx, source)).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: testSyncKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(applies).To(Equal(1))
+		Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(destination), destination)).To(Succeed())
+		Expect(destination.Annotations[SourceHashAnnotation]).To(HavePrefix("sha256-v2:"))
+	})
+})
@@ -0,0 +1,140 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Sync results used as the "result" label.
+const (
+	syncResultSuccess = "success"
+	syncResultFailed  = "failed"
+	syncResultError   = "error"
+)
+
+var (
+	syncAttemptsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "configmapsync_sync_attempts_total",
+			Help: "Number of sync attempts by result (success, failed, error).",
+		},
+		[]string{"result"},
+	)
+
+	syncDurationSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "configmapsync_sync_duration_seconds",
+			Help:    "Time taken by a sync attempt, by result.",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 12),
+		},
+		[]string{"result"},
+	)
+
+	bytesCopiedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "configmapsync_bytes_copied_total",
+			Help: "Bytes of ConfigMap data written to destinations.",
+		},
+	)
+
+	driftCorrectionsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "configmapsync_drift_corrections_total",
+			Help: "Destinations that were modified out of band and restored from an unchanged source.",
+		},
+	)
+
+	lastSuccessTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "configmapsync_last_success_timestamp_seconds",
+			Help: "Unix time of the last successful sync of each ConfigMapSync.",
+		},
+		[]string{"name", "namespace"},
+	)
+
+	syncStatus = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "configmapsync_sync_status",
+			Help: "1 if the ConfigMapSync's last sync succeeded, 0 otherwise.",
+		},
+		[]string{"name", "namespace"},
+	)
+
+	shardsOwned = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "configmapsync_shards_owned",
+			Help: "Number of shards this replica processes when sharding is enabled.",
+		},
+	)
+
+	orphanedDestinations = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "configmapsync_orphaned_destinations",
+			Help: "Orphaned destination ConfigMaps found by the last orphan sweep.",
+		},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		syncAttemptsTotal,
+		syncDurationSeconds,
+		bytesCopiedTotal,
+		driftCorrectionsTotal,
+		lastSuccessTimestamp,
+		syncStatus,
+		shardsOwned,
+		orphanedDestinations,
+	)
+}
+
+// recordSyncMetrics records the outcome of one sync attempt. The per-CR
+// gauges are only touched when PerSyncMetrics is enabled, which keeps series
+// cardinality flat on clusters with many ConfigMapSyncs.
+func (r *ConfigMapSyncReconciler) recordSyncMetrics(configMapSync *appsv2.ConfigMapSync, start time.Time, err error) {
+	result := syncResultFailed
+	switch {
+	case err != nil:
+		result = syncResultError
+	case configMapSync.Status.SyncStatus == appsv2.SyncSucceeded:
+		result = syncResultSuccess
+	}
+	syncAttemptsTotal.WithLabelValues(result).Inc()
+	syncDurationSeconds.WithLabelValues(result).Observe(time.Since(start).Seconds())
+
+	if !r.PerSyncMetrics {
+		return
+	}
+	if result == syncResultSuccess {
+		lastSuccessTimestamp.WithLabelValues(configMapSync.Name, configMapSync.Namespace).SetToCurrentTime()
+		syncStatus.WithLabelValues(configMapSync.Name, configMapSync.Namespace).Set(1)
+	} else {
+		syncStatus.WithLabelValues(configMapSync.Name, configMapSync.Namespace).Set(0)
+	}
+}
+
+// forgetSyncMetrics drops the per-CR series of a deleted ConfigMapSync.
+func forgetSyncMetrics(configMapSync *appsv2.ConfigMapSync) {
+	lastSuccessTimestamp.DeleteLabelValues(configMapSync.Name, configMapSync.Namespace)
+	syncStatus.DeleteLabelValues(configMapSync.Name, configMapSync.Namespace)
+}
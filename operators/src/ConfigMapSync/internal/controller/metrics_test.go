@@ -0,0 +1,66 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+var _ = Describe("Sync metrics", func() {
+	newSync := func(name string) *appsv2.ConfigMapSync {
+		return &appsv2.ConfigMapSync{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "metrics-test"}}
+	}
+
+	It("should count attempts by result and track per-CR status", func() {
+		reconciler := &ConfigMapSyncReconciler{PerSyncMetrics: true}
+		successes := testutil.ToFloat64(syncAttemptsTotal.WithLabelValues(syncResultSuccess))
+		errorsBefore := testutil.ToFloat64(syncAttemptsTotal.WithLabelValues(syncResultError))
+
+		configMapSync := newSync("tracked")
+		configMapSync.Status.SyncStatus = appsv2.SyncSucceeded
+		reconciler.recordSyncMetrics(configMapSync, time.Now(), nil)
+		Expect(testutil.ToFloat64(syncAttemptsTotal.WithLabelValues(syncResultSuccess))).To(Equal(successes + 1))
+		Expect(testutil.ToFloat64(syncStatus.WithLabelValues("tracked", "metrics-test"))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(lastSuccessTimestamp.WithLabelValues("tracked", "metrics-test"))).To(BeNumerically(">", 0))
+
+		reconciler.recordSyncMetrics(configMapSync, time.Now(), errors.New("boom"))
+		Expect(testutil.ToFloat64(syncAttemptsTotal.WithLabelValues(syncResultError))).To(Equal(errorsBefore + 1))
+		Expect(testutil.ToFloat64(syncStatus.WithLabelValues("tracked", "metrics-test"))).To(Equal(0.0))
+
+		forgetSyncMetrics(configMapSync)
+		Expect(syncStatus.DeleteLabelValues("tracked", "metrics-test")).To(BeFalse())
+	})
+
+	It("should not create per-CR series when they are disabled", func() {
+		reconciler := &ConfigMapSyncReconciler{}
+		configMapSync := newSync("untracked")
+		configMapSync.Status.SyncStatus = appsv2.SyncSucceeded
+		reconciler.recordSyncMetrics(configMapSync, time.Now(), nil)
+
+		Expect(syncStatus.DeleteLabelValues("untracked", "metrics-test")).To(BeFalse())
+		Expect(lastSuccessTimestamp.DeleteLabelValues("untracked", "metrics-test")).To(BeFalse())
+	})
+})
@@ -0,0 +1,216 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+// OrphanPolicy decides what the orphan collector does with a destination
+// ConfigMap whose ConfigMapSync is gone or no longer targets it.
+type OrphanPolicy string
+
+const (
+	// OrphanPolicyDelete deletes orphaned destinations.
+	OrphanPolicyDelete OrphanPolicy = "delete"
+	// OrphanPolicyReport leaves orphans in place and emits an Orphaned
+	// Warning event on each of them.
+	OrphanPolicyReport OrphanPolicy = "report"
+	// OrphanPolicyDryRun only logs the orphans that delete would remove.
+	OrphanPolicyDryRun OrphanPolicy = "dry-run"
+	// OrphanPolicyOff disables the collector.
+	OrphanPolicyOff OrphanPolicy = "off"
+)
+
+// DefaultOrphanSweepInterval is how often the orphan collector runs.
+const DefaultOrphanSweepInterval = 10 * time.Minute
+
+// ParseOrphanPolicy validates an orphan policy flag value.
+func ParseOrphanPolicy(value string) (OrphanPolicy, error) {
+	policy := OrphanPolicy(value)
+	switch policy {
+	case OrphanPolicyDelete, OrphanPolicyReport, OrphanPolicyDryRun, OrphanPolicyOff:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown orphan policy %q, must be one of delete, report, dry-run or off", value)
+}
+
+// OrphanCollector periodically sweeps the destination ConfigMaps for
+// orphans: ConfigMaps carrying the sync labels whose ConfigMapSync was
+// deleted without cleaning up, for example when its finalizer was removed by
+// hand, or whose ConfigMapSync no longer lists them as a destination.
+type OrphanCollector struct {
+	// Client lists the destinations, normally from the cache.
+	Client client.Client
+	// APIReader confirms an orphan against the API server, so that a
+	// ConfigMapSync missing from a lagging cache is not mistaken for gone.
+	APIReader client.Reader
+	Recorder  record.EventRecorder
+	Policy    OrphanPolicy
+	Interval  time.Duration
+	// Shards, when set, limits the sweep to ConfigMaps of ConfigMapSyncs in
+	// shards this replica owns.
+	Shards *ShardManager
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. With
+// sharding every replica sweeps its own shards.
+func (c *OrphanCollector) NeedLeaderElection() bool {
+	return c.Shards == nil
+}
+
+// Start implements manager.Runnable.
+func (c *OrphanCollector) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("orphan-collector")
+	ctx = log.IntoContext(ctx, logger)
+	interval := c.Interval
+	if interval <= 0 {
+		interval = DefaultOrphanSweepInterval
+	}
+	logger.Info("Starting orphan collector", "policy", c.Policy, "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := c.Sweep(ctx); err != nil {
+			logger.Error(err, "Failed to sweep for orphaned destinations")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep finds the orphaned destinations and handles them according to the
+// policy. It returns the orphans found.
+func (c *OrphanCollector) Sweep(ctx context.Context) ([]types.NamespacedName, error) {
+	destinations := &corev1.ConfigMapList{}
+	if err := c.Client.List(ctx, destinations,
+		client.MatchingLabels{LabelManagedBy: ManagedByValue},
+		client.HasLabels{LabelSyncName, LabelSyncNamespace},
+	); err != nil {
+		return nil, fmt.Errorf("listing managed ConfigMaps: %w", err)
+	}
+
+	var orphans []types.NamespacedName
+	var errs []error
+	for i := range destinations.Items {
+		destination := &destinations.Items[i]
+		syncKey := types.NamespacedName{
+			Namespace: destination.Labels[LabelSyncNamespace],
+			Name:      destination.Labels[LabelSyncName],
+		}
+		collectCtx, done := ctx, func() {}
+		if c.Shards != nil {
+			var owned bool
+			if collectCtx, done, owned = c.Shards.Begin(ctx, syncKey.Namespace); !owned {
+				continue
+			}
+		}
+		if err := c.collect(collectCtx, syncKey, destination, &orphans); err != nil {
+			errs = append(errs, err)
+		}
+		done()
+	}
+	orphanedDestinations.Set(float64(len(orphans)))
+	return orphans, errors.Join(errs...)
+}
+
+// collect handles destination according to the policy if it is an orphan of
+// the ConfigMapSync syncKey, adding it to orphans.
+func (c *OrphanCollector) collect(ctx context.Context, syncKey types.NamespacedName, destination *corev1.ConfigMap, orphans *[]types.NamespacedName) error {
+	logger := log.FromContext(ctx)
+	reason, err := c.orphaned(ctx, syncKey, destination)
+	if err != nil || reason == "" {
+		return err
+	}
+	key := client.ObjectKeyFromObject(destination)
+	*orphans = append(*orphans, key)
+
+	switch c.Policy {
+	case OrphanPolicyDelete:
+		err := c.Client.Delete(ctx, destination, client.Preconditions{
+			UID:             &destination.UID,
+			ResourceVersion: &destination.ResourceVersion,
+		})
+		if client.IgnoreNotFound(err) != nil {
+			// A conflict means the ConfigMap changed, it is checked again next sweep
+			return fmt.Errorf("deleting orphaned ConfigMap %s: %w", key, err)
+		}
+		logger.Info("Deleted orphaned destination ConfigMap", "configMap", key, "reason", reason)
+		c.event(destination, corev1.EventTypeNormal, EventReasonOrphanDeleted, "Deleted orphaned ConfigMap: "+reason)
+	case OrphanPolicyReport:
+		logger.Info("Found orphaned destination ConfigMap", "configMap", key, "reason", reason)
+		c.event(destination, corev1.EventTypeWarning, EventReasonOrphaned, "ConfigMap is orphaned: "+reason)
+	default:
+		logger.Info("Dry run: would delete orphaned destination ConfigMap", "configMap", key, "reason", reason)
+	}
+	return nil
+}
+
+// orphaned explains why destination is an orphan of the ConfigMapSync
+// syncKey, or returns an empty string when it is not.
+func (c *OrphanCollector) orphaned(ctx context.Context, syncKey types.NamespacedName, destination *corev1.ConfigMap) (string, error) {
+	reason, err := orphanReason(ctx, c.Client, syncKey, destination)
+	if err != nil || reason == "" || c.APIReader == nil {
+		return reason, err
+	}
+	// Confirm against the API server before acting on it
+	return orphanReason(ctx, c.APIReader, syncKey, destination)
+}
+
+// orphanReason checks destination against the ConfigMapSync syncKey as read
+// through reader.
+func orphanReason(ctx context.Context, reader client.Reader, syncKey types.NamespacedName, destination *corev1.ConfigMap) (string, error) {
+	configMapSync := &appsv2.ConfigMapSync{}
+	if err := reader.Get(ctx, syncKey, configMapSync); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Sprintf("ConfigMapSync %s no longer exists", syncKey), nil
+		}
+		return "", fmt.Errorf("fetching ConfigMapSync %s: %w", syncKey, err)
+	}
+	// The finalizer cleans up the destinations of a ConfigMapSync being deleted
+	if configMapSync.DeletionTimestamp != nil {
+		return "", nil
+	}
+	if !slices.Contains(destinationKeys(configMapSync), client.ObjectKeyFromObject(destination)) {
+		return fmt.Sprintf("ConfigMapSync %s no longer targets it", syncKey), nil
+	}
+	return "", nil
+}
+
+// event records an event on an orphaned destination.
+func (c *OrphanCollector) event(destination *corev1.ConfigMap, eventType, reason, message string) {
+	if c.Recorder != nil {
+		c.Recorder.Event(destination, eventType, reason, message)
+	}
+}
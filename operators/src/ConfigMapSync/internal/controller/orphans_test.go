@@ -0,0 +1,151 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+var _ = Describe("Orphan collector", func() {
+	ctx := context.Background()
+
+	var (
+		testScheme *runtime.Scheme
+		c          client.Client
+		recorder   *record.FakeRecorder
+	)
+
+	destination := func(namespace, name, syncName string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels: map[string]string{
+					LabelManagedBy:     ManagedByValue,
+					LabelSyncName:      syncName,
+					LabelSyncNamespace: "team-a",
+				},
+			},
+		}
+	}
+	configMapSync := func(name string, destinations ...string) *appsv2.ConfigMapSync {
+		configMapSync := &appsv2.ConfigMapSync{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team-a"},
+			Spec:       appsv2.ConfigMapSyncSpec{Source: appsv2.SourceReference{Namespace: "team-a", Name: "app-config"}},
+		}
+		for _, namespace := range destinations {
+			configMapSync.Spec.Destinations = append(configMapSync.Spec.Destinations, appsv2.DestinationReference{Namespace: namespace})
+		}
+		return configMapSync
+	}
+
+	BeforeEach(func() {
+		testScheme = runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(testScheme)).To(Succeed())
+		Expect(appsv2.AddToScheme(testScheme)).To(Succeed())
+
+		deleting := configMapSync("deleting-sync", "tenant-1")
+		deleting.Finalizers = []string{ConfigMapSyncFinalizer}
+		deleting.DeletionTimestamp = &metav1.Time{Time: metav1.Now().Time}
+		c = fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+			configMapSync("app-sync", "tenant-1"),
+			deleting,
+			// Still a destination
+			destination("tenant-1", "app-config", "app-sync"),
+			// The ConfigMapSync was changed to target tenant-1 only
+			destination("tenant-2", "app-config", "app-sync"),
+			// The ConfigMapSync was force-deleted
+			destination("tenant-1", "old-config", "removed-sync"),
+			// Cleaned up by the finalizer
+			destination("tenant-1", "app-config-deleting", "deleting-sync"),
+			// Not created by the operator
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "tenant-1"}},
+		).Build()
+		recorder = record.NewFakeRecorder(10)
+	})
+
+	sweep := func(policy OrphanPolicy) []types.NamespacedName {
+		collector := &OrphanCollector{Client: c, APIReader: c, Recorder: recorder, Policy: policy}
+		orphans, err := collector.Sweep(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		return orphans
+	}
+	remaining := func() []string {
+		configMaps := &corev1.ConfigMapList{}
+		Expect(c.List(ctx, configMaps)).To(Succeed())
+		var names []string
+		for _, configMap := range configMaps.Items {
+			names = append(names, configMap.Namespace+"/"+configMap.Name)
+		}
+		return names
+	}
+	expectedOrphans := []types.NamespacedName{
+		{Namespace: "tenant-1", Name: "old-config"},
+		{Namespace: "tenant-2", Name: "app-config"},
+	}
+
+	It("should only log orphans in dry-run mode", func() {
+		Expect(sweep(OrphanPolicyDryRun)).To(ConsistOf(expectedOrphans))
+		Expect(remaining()).To(HaveLen(5))
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("should report orphans with events", func() {
+		Expect(sweep(OrphanPolicyReport)).To(ConsistOf(expectedOrphans))
+		Expect(remaining()).To(HaveLen(5))
+		Expect(recorder.Events).To(HaveLen(2))
+		Expect(<-recorder.Events).To(HavePrefix("Warning Orphaned ConfigMap is orphaned"))
+	})
+
+	It("should delete orphans and keep everything else", func() {
+		Expect(sweep(OrphanPolicyDelete)).To(ConsistOf(expectedOrphans))
+		Expect(remaining()).To(ConsistOf(
+			"tenant-1/app-config", "tenant-1/app-config-deleting", "tenant-1/unrelated"))
+		Expect(sweep(OrphanPolicyDelete)).To(BeEmpty())
+	})
+
+	It("should not delete destinations of a ConfigMapSync missing from a lagging cache", func() {
+		lagging := fake.NewClientBuilder().WithScheme(testScheme).
+			WithObjects(destination("tenant-1", "app-config", "new-sync")).Build()
+		apiServer := fake.NewClientBuilder().WithScheme(testScheme).
+			WithObjects(configMapSync("new-sync", "tenant-1")).Build()
+		collector := &OrphanCollector{Client: lagging, APIReader: apiServer, Policy: OrphanPolicyDelete}
+		orphans, err := collector.Sweep(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(orphans).To(BeEmpty())
+	})
+
+	It("should reject unknown policies", func() {
+		_, err := ParseOrphanPolicy("purge")
+		Expect(err).To(HaveOccurred())
+		Expect(ParseOrphanPolicy("dry-run")).To(Equal(OrphanPolicyDryRun))
+	})
+})
@@ -0,0 +1,204 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// permissionCacheTTL bounds how long an access review result is trusted.
+// RBAC changes are picked up after at most this long.
+const permissionCacheTTL = 5 * time.Minute
+
+// accessRequirement is a single verb on a core resource in a namespace.
+type accessRequirement struct {
+	Verb      string
+	Resource  string
+	Namespace string
+}
+
+func (a accessRequirement) String() string {
+	return fmt.Sprintf("%s %s in namespace %s", a.Verb, a.Resource, a.Namespace)
+}
+
+type cachedAccess struct {
+	allowed bool
+	expires time.Time
+}
+
+// permissionCache answers "may the operator do X" through
+// SelfSubjectAccessReviews and remembers the answers for a while, so that
+// a reconcile does not cost one API call per verb.
+type permissionCache struct {
+	client client.Client
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	results map[accessRequirement]cachedAccess
+}
+
+func newPermissionCache(c client.Client, ttl time.Duration) *permissionCache {
+	return &permissionCache{
+		client:  c,
+		ttl:     ttl,
+		results: make(map[accessRequirement]cachedAccess),
+	}
+}
+
+// allowed runs (or reuses) an access review for a single requirement.
+func (p *permissionCache) allowed(ctx context.Context, requirement accessRequirement) (bool, error) {
+	p.mu.Lock()
+	cached, ok := p.results[requirement]
+	p.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.allowed, nil
+	}
+
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: requirement.Namespace,
+				Verb:      requirement.Verb,
+				Resource:  requirement.Resource,
+			},
+		},
+	}
+	if err := p.client.Create(ctx, review); err != nil {
+		return false, fmt.Errorf("access review for %s failed: %w", requirement, err)
+	}
+
+	p.mu.Lock()
+	p.results[requirement] = cachedAccess{allowed: review.Status.Allowed, expires: time.Now().Add(p.ttl)}
+	p.mu.Unlock()
+	return review.Status.Allowed, nil
+}
+
+// missing returns the requirements the operator does not hold.
+func (p *permissionCache) missing(ctx context.Context, requirements []accessRequirement) ([]accessRequirement, error) {
+	var denied []accessRequirement
+	for _, requirement := range requirements {
+		ok, err := p.allowed(ctx, requirement)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			denied = append(denied, requirement)
+		}
+	}
+	return denied, nil
+}
+
+// requiredPermissions lists the exact verbs Reconcile uses for a ConfigMapSync.
+func requiredPermissions(configMapSync *appsv2.ConfigMapSync) []accessRequirement {
+	requirements := []accessRequirement{
+		{Verb: "get", Resource: "configmaps", Namespace: configMapSync.Spec.Source.Namespace},
+	}
+	seen := map[string]bool{}
+	for _, destination := range destinationKeys(configMapSync) {
+		if seen[destination.Namespace] {
+			continue
+		}
+		seen[destination.Namespace] = true
+		for _, verb := range []string{"get", "create", "patch", "delete"} {
+			requirements = append(requirements, accessRequirement{Verb: verb, Resource: "configmaps", Namespace: destination.Namespace})
+		}
+	}
+	if configMapSync.Spec.SignatureVerification != nil {
+		resource := "secrets"
+		if configMapSync.Spec.SignatureVerification.PublicKeysRef.Kind == "ConfigMap" {
+			resource = "configmaps"
+		}
+		requirements = append(requirements, accessRequirement{Verb: "get", Resource: resource, Namespace: configMapSync.Namespace})
+	}
+	if configMapSync.Spec.Decryption != nil {
+		requirements = append(requirements, accessRequirement{Verb: "get", Resource: "secrets", Namespace: configMapSync.Namespace})
+	}
+	return requirements
+}
+
+func describeRequirements(requirements []accessRequirement) string {
+	parts := make([]string, 0, len(requirements))
+	for _, requirement := range requirements {
+		parts = append(parts, requirement.String())
+	}
+	return strings.Join(parts, "; ")
+}
+
+// permissionSummary logs, once at startup, the namespaces in which the
+// operator cannot read ConfigMaps. It is registered with the manager so
+// that it runs once the caches are ready. When the operator is restricted
+// to some namespaces only those are checked.
+type permissionSummary struct {
+	client      client.Client
+	permissions *permissionCache
+	namespaces  NamespaceScope
+}
+
+// Start implements manager.Runnable.
+func (s *permissionSummary) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("permission-preflight")
+
+	names := s.namespaces.Namespaces()
+	if !s.namespaces.Restricted() {
+		clusterWide, err := s.permissions.allowed(ctx, accessRequirement{Verb: "get", Resource: "configmaps"})
+		if err != nil {
+			logger.Error(err, "Failed to run permission preflight")
+			return nil
+		}
+		if clusterWide {
+			logger.Info("Operator can read ConfigMaps in all namespaces")
+			return nil
+		}
+
+		namespaces := &corev1.NamespaceList{}
+		if err := s.client.List(ctx, namespaces); err != nil {
+			logger.Error(err, "Operator cannot list namespaces, unable to determine which namespaces it can see")
+			return nil
+		}
+		for _, ns := range namespaces.Items {
+			names = append(names, ns.Name)
+		}
+	}
+
+	var blind []string
+	for _, name := range names {
+		ok, err := s.permissions.allowed(ctx, accessRequirement{Verb: "get", Resource: "configmaps", Namespace: name})
+		if err != nil {
+			logger.Error(err, "Failed to run permission preflight", "namespace", name)
+			return nil
+		}
+		if !ok {
+			blind = append(blind, name)
+		}
+	}
+	if len(blind) > 0 {
+		logger.Info("Operator cannot read ConfigMaps in some namespaces, syncs involving them will not work",
+			"namespaces", blind)
+	}
+	return nil
+}
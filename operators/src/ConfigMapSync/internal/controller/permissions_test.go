@@ -0,0 +1,73 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+var _ = Describe("RBAC permission preflight", func() {
+	ctx := context.Background()
+
+	It("should report each missing verb and cache review results", func() {
+		reviews := 0
+		c := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				review, ok := obj.(*authorizationv1.SelfSubjectAccessReview)
+				if !ok {
+					return c.Create(ctx, obj, opts...)
+				}
+				reviews++
+				attributes := review.Spec.ResourceAttributes
+				// The operator may only read in the destination namespace.
+				review.Status.Allowed = attributes.Namespace != "tenant-1" || attributes.Verb == "get"
+				return nil
+			},
+		}).Build()
+		permissions := newPermissionCache(c, time.Minute)
+
+		configMapSync := &appsv2.ConfigMapSync{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-sync", Namespace: "team-a"},
+			Spec: appsv2.ConfigMapSyncSpec{
+				Source:       appsv2.SourceReference{Namespace: "team-a", Name: "app-config"},
+				Destinations: []appsv2.DestinationReference{{Namespace: "tenant-1"}},
+			},
+		}
+
+		missing, err := permissions.missing(ctx, requiredPermissions(configMapSync))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(describeRequirements(missing)).To(Equal(
+			"create configmaps in namespace tenant-1; patch configmaps in namespace tenant-1; delete configmaps in namespace tenant-1"))
+		Expect(reviews).To(Equal(5))
+
+		_, err = permissions.missing(ctx, requiredPermissions(configMapSync))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reviews).To(Equal(5))
+	})
+})
@@ -0,0 +1,172 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Namespace annotations that override the cluster-wide quota limits. The
+// destination limit is read from the ConfigMapSync's namespace, the byte
+// limits from the source namespace. Values are resource quantities, e.g. "512Ki".
+const (
+	QuotaMaxDestinationsAnnotation = "quota.configmapsync.apps.kapendra.com/max-destinations-per-sync"
+	QuotaMaxBytesAnnotation        = "quota.configmapsync.apps.kapendra.com/max-bytes-per-source-namespace"
+	QuotaMaxObjectSizeAnnotation   = "quota.configmapsync.apps.kapendra.com/max-object-size"
+)
+
+// QuotaLimits bounds how much a tenant can fan out. A zero value means the
+// limit is not enforced.
+type QuotaLimits struct {
+	// MaxDestinationsPerSync caps the destinations managed by one ConfigMapSync.
+	MaxDestinationsPerSync int64
+	// MaxBytesPerSourceNamespace caps the total size of all destinations
+	// synced from one source namespace.
+	MaxBytesPerSourceNamespace int64
+	// MaxObjectSize caps the size of a single destination ConfigMap.
+	MaxObjectSize int64
+}
+
+// configMapSize approximates the stored size of a ConfigMap by its keys and
+// values, which dominate for anything large enough to matter.
+func configMapSize(configMap *corev1.ConfigMap) int64 {
+	var size int64
+	for key, value := range configMap.Data {
+		size += int64(len(key) + len(value))
+	}
+	for key, value := range configMap.BinaryData {
+		size += int64(len(key) + len(value))
+	}
+	return size
+}
+
+// effectiveQuota returns the cluster-wide limits with any overrides from the
+// namespace annotations applied.
+func (r *ConfigMapSyncReconciler) effectiveQuota(ctx context.Context, syncNamespace, sourceNamespace string) (QuotaLimits, error) {
+	limits := r.Quotas
+
+	override := func(namespace, annotation string, limit *int64) error {
+		ns := &corev1.Namespace{}
+		if err := r.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+			// A namespace-scoped operator may not be allowed to read the
+			// namespace; the cluster-wide limits apply then
+			if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+				return nil
+			}
+			return err
+		}
+		value, ok := ns.Annotations[annotation]
+		if !ok {
+			return nil
+		}
+		quantity, err := resource.ParseQuantity(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid %s annotation on namespace %s: %w", annotation, namespace, err)
+		}
+		*limit = quantity.Value()
+		return nil
+	}
+
+	if err := override(syncNamespace, QuotaMaxDestinationsAnnotation, &limits.MaxDestinationsPerSync); err != nil {
+		return limits, err
+	}
+	if err := override(sourceNamespace, QuotaMaxBytesAnnotation, &limits.MaxBytesPerSourceNamespace); err != nil {
+		return limits, err
+	}
+	if err := override(sourceNamespace, QuotaMaxObjectSizeAnnotation, &limits.MaxObjectSize); err != nil {
+		return limits, err
+	}
+	return limits, nil
+}
+
+// checkQuota verifies that writing the desired destinations stays within the
+// limits. Current usage is tallied from the sync labels on existing
+// destinations, and objects that are about to be replaced are counted at
+// their new size. It returns a human readable violation, or an empty string
+// when the write is allowed.
+func (r *ConfigMapSyncReconciler) checkQuota(ctx context.Context, configMapSync *appsv2.ConfigMapSync, desired []*corev1.ConfigMap) (string, error) {
+	limits, err := r.effectiveQuota(ctx, configMapSync.Namespace, configMapSync.Spec.Source.Namespace)
+	if err != nil {
+		return "", err
+	}
+	if limits == (QuotaLimits{}) {
+		return "", nil
+	}
+
+	desiredKeys := make(map[types.NamespacedName]bool, len(desired))
+	for _, configMap := range desired {
+		desiredKeys[client.ObjectKeyFromObject(configMap)] = true
+		if limits.MaxObjectSize > 0 && configMapSize(configMap) > limits.MaxObjectSize {
+			return fmt.Sprintf("destination %s would be %d bytes, exceeding the maximum object size of %d bytes",
+				client.ObjectKeyFromObject(configMap), configMapSize(configMap), limits.MaxObjectSize), nil
+		}
+	}
+
+	// Destinations dropped from the spec are cleaned up once the sync
+	// succeeds, so only the desired ones count. Counting the others would
+	// block a sync at its limit from ever swapping a destination
+	if limits.MaxDestinationsPerSync > 0 && int64(len(desiredKeys)) > limits.MaxDestinationsPerSync {
+		return fmt.Sprintf("ConfigMapSync would manage %d destinations, exceeding the limit of %d",
+			len(desiredKeys), limits.MaxDestinationsPerSync), nil
+	}
+
+	if limits.MaxBytesPerSourceNamespace > 0 {
+		// Destinations are attributed to a source namespace through the
+		// ConfigMapSync that their sync labels name
+		syncs := &appsv2.ConfigMapSyncList{}
+		if err := r.List(ctx, syncs); err != nil {
+			return "", err
+		}
+		sourceNamespaces := make(map[types.NamespacedName]string, len(syncs.Items))
+		for i := range syncs.Items {
+			sourceNamespaces[client.ObjectKeyFromObject(&syncs.Items[i])] = syncs.Items[i].Spec.Source.Namespace
+		}
+		sourceNamespaces[client.ObjectKeyFromObject(configMapSync)] = configMapSync.Spec.Source.Namespace
+
+		synced := &corev1.ConfigMapList{}
+		if err := r.List(ctx, synced, client.MatchingLabels{LabelManagedBy: ManagedByValue}); err != nil {
+			return "", err
+		}
+		var total int64
+		for i := range synced.Items {
+			destination := &synced.Items[i]
+			owner := types.NamespacedName{Namespace: destination.Labels[LabelSyncNamespace], Name: destination.Labels[LabelSyncName]}
+			if sourceNamespaces[owner] == configMapSync.Spec.Source.Namespace && !desiredKeys[client.ObjectKeyFromObject(destination)] {
+				total += configMapSize(destination)
+			}
+		}
+		for _, configMap := range desired {
+			total += configMapSize(configMap)
+		}
+		if total > limits.MaxBytesPerSourceNamespace {
+			return fmt.Sprintf("ConfigMaps synced from namespace %s would total %d bytes, exceeding the limit of %d bytes",
+				configMapSync.Spec.Source.Namespace, total, limits.MaxBytesPerSourceNamespace), nil
+		}
+	}
+
+	return "", nil
+}
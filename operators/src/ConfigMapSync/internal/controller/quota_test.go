@@ -0,0 +1,127 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+var _ = Describe("Sync quotas", func() {
+	ctx := context.Background()
+
+	configMapSync := &appsv2.ConfigMapSync{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-sync", Namespace: "team-a"},
+		Spec: appsv2.ConfigMapSyncSpec{
+			Source:       appsv2.SourceReference{Namespace: "team-a", Name: "app-config"},
+			Destinations: []appsv2.DestinationReference{{Namespace: "tenant-1"}},
+		},
+	}
+
+	// otherSync reads from another source namespace
+	otherSync := &appsv2.ConfigMapSync{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-sync", Namespace: "team-b"},
+		Spec: appsv2.ConfigMapSyncSpec{
+			Source:       appsv2.SourceReference{Namespace: "team-b", Name: "app-config"},
+			Destinations: []appsv2.DestinationReference{{Namespace: "tenant-1"}},
+		},
+	}
+
+	ownedConfigMap := func(owner *appsv2.ConfigMapSync, namespace, name string, size int) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    syncLabels(owner),
+			},
+			Data: map[string]string{"k": strings.Repeat("x", size-1)},
+		}
+	}
+	syncedConfigMap := func(namespace, name string, size int) *corev1.ConfigMap {
+		return ownedConfigMap(configMapSync, namespace, name, size)
+	}
+
+	It("should reject objects over the maximum size", func() {
+		reconciler := &ConfigMapSyncReconciler{
+			Client: fake.NewClientBuilder().Build(),
+			Quotas: QuotaLimits{MaxObjectSize: 1024},
+		}
+
+		violation, err := reconciler.checkQuota(ctx, configMapSync, []*corev1.ConfigMap{
+			syncedConfigMap("tenant-1", "app-config", 2048),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(violation).To(ContainSubstring("maximum object size of 1024 bytes"))
+	})
+
+	It("should tally bytes from labelled destinations and not double count replacements", func() {
+		testScheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(testScheme)).To(Succeed())
+		Expect(appsv2.AddToScheme(testScheme)).To(Succeed())
+		reconciler := &ConfigMapSyncReconciler{
+			Client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+				otherSync,
+				syncedConfigMap("tenant-1", "app-config", 600),
+				syncedConfigMap("tenant-2", "other-config", 300),
+				// Synced from team-b, so it does not count
+				ownedConfigMap(otherSync, "tenant-3", "app-config", 5000),
+			).Build(),
+			Quotas: QuotaLimits{MaxBytesPerSourceNamespace: 1000},
+		}
+
+		violation, err := reconciler.checkQuota(ctx, configMapSync, []*corev1.ConfigMap{
+			syncedConfigMap("tenant-1", "app-config", 700),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(violation).To(BeEmpty())
+
+		violation, err = reconciler.checkQuota(ctx, configMapSync, []*corev1.ConfigMap{
+			syncedConfigMap("tenant-1", "app-config", 701),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(violation).To(ContainSubstring("would total 1001 bytes"))
+	})
+
+	It("should let namespace annotations override the cluster-wide limits", func() {
+		reconciler := &ConfigMapSyncReconciler{
+			Client: fake.NewClientBuilder().WithObjects(&corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "team-a",
+					Annotations: map[string]string{QuotaMaxObjectSizeAnnotation: "4Ki"},
+				},
+			}).Build(),
+			Quotas: QuotaLimits{MaxObjectSize: 1024},
+		}
+
+		violation, err := reconciler.checkQuota(ctx, configMapSync, []*corev1.ConfigMap{
+			syncedConfigMap("tenant-1", "app-config", 2048),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(violation).To(BeEmpty())
+	})
+})
@@ -0,0 +1,139 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Default retry delays for failed reconciles.
+const (
+	DefaultRetryBaseDelay = 5 * time.Second
+	DefaultRetryMaxDelay  = 10 * time.Minute
+)
+
+// RetryOptions configures how soon a failed reconcile is retried. The delay
+// doubles with every consecutive failure of the same ConfigMapSync, from
+// BaseDelay up to MaxDelay, and resets once a reconcile succeeds.
+type RetryOptions struct {
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// newRetryBackoff returns the per-item backoff for options, with unset
+// delays defaulted.
+func newRetryBackoff(options RetryOptions) *jitteredExponentialRateLimiter {
+	if options.BaseDelay <= 0 {
+		options.BaseDelay = DefaultRetryBaseDelay
+	}
+	if options.MaxDelay < options.BaseDelay {
+		options.MaxDelay = max(DefaultRetryMaxDelay, options.BaseDelay)
+	}
+	return newJitteredExponentialRateLimiter(options.BaseDelay, options.MaxDelay)
+}
+
+// newRetryRateLimiter returns the controller's workqueue rate limiter: the
+// per-item jittered exponential backoff, bounded by an overall token bucket
+// like controller-runtime's default.
+func newRetryRateLimiter(backoff *jitteredExponentialRateLimiter) workqueue.TypedRateLimiter[reconcile.Request] {
+	return workqueue.NewTypedMaxOfRateLimiter(
+		backoff,
+		&workqueue.TypedBucketRateLimiter[reconcile.Request]{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+	)
+}
+
+// jitteredExponentialRateLimiter backs off exponentially per item. Each
+// delay is drawn from the upper half of the backoff, so ConfigMapSyncs that
+// failed together, for example during an API server outage, do not retry in
+// lockstep.
+type jitteredExponentialRateLimiter struct {
+	baseDelay time.Duration
+	maxDelay  time.Duration
+	// jitter returns a value in [0, 1); tests replace it.
+	jitter func() float64
+
+	mu       sync.Mutex
+	failures map[reconcile.Request]int
+	// policies override the delays of items whose spec sets a retry policy.
+	policies map[reconcile.Request]RetryOptions
+}
+
+func newJitteredExponentialRateLimiter(baseDelay, maxDelay time.Duration) *jitteredExponentialRateLimiter {
+	return &jitteredExponentialRateLimiter{
+		baseDelay: baseDelay,
+		maxDelay:  maxDelay,
+		jitter:    rand.Float64,
+		failures:  map[reconcile.Request]int{},
+		policies:  map[reconcile.Request]RetryOptions{},
+	}
+}
+
+// setPolicy overrides the delays of item; unset delays keep the limiter's.
+// A nil policy removes the override.
+func (l *jitteredExponentialRateLimiter) setPolicy(item reconcile.Request, policy *RetryOptions) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if policy == nil {
+		delete(l.policies, item)
+		return
+	}
+	l.policies[item] = *policy
+}
+
+// When records a failure of item and returns how long to wait before retrying it.
+func (l *jitteredExponentialRateLimiter) When(item reconcile.Request) time.Duration {
+	l.mu.Lock()
+	failures := l.failures[item]
+	l.failures[item] = failures + 1
+	baseDelay, maxDelay := l.baseDelay, l.maxDelay
+	if policy, ok := l.policies[item]; ok {
+		if policy.BaseDelay > 0 {
+			baseDelay = policy.BaseDelay
+		}
+		if policy.MaxDelay > 0 {
+			maxDelay = policy.MaxDelay
+		}
+	}
+	l.mu.Unlock()
+
+	backoff := float64(baseDelay) * math.Pow(2, float64(failures))
+	if backoff > float64(maxDelay) {
+		backoff = float64(maxDelay)
+	}
+	return time.Duration(backoff/2 + l.jitter()*backoff/2)
+}
+
+// NumRequeues returns the consecutive failures recorded for item.
+func (l *jitteredExponentialRateLimiter) NumRequeues(item reconcile.Request) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.failures[item]
+}
+
+// Forget resets the backoff of item after a successful reconcile.
+func (l *jitteredExponentialRateLimiter) Forget(item reconcile.Request) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.failures, item)
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Retry rate limiter", func() {
+	item := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "app-sync"}}
+	other := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "other-sync"}}
+
+	It("should double the delay per failure up to the maximum", func() {
+		limiter := newJitteredExponentialRateLimiter(time.Second, 5*time.Second)
+		limiter.jitter = func() float64 { return 1 }
+
+		var delays []time.Duration
+		for range 5 {
+			delays = append(delays, limiter.When(item))
+		}
+		Expect(delays).To(Equal([]time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}))
+		Expect(limiter.NumRequeues(item)).To(Equal(5))
+		Expect(limiter.When(other)).To(Equal(time.Second))
+	})
+
+	It("should jitter within the upper half of the backoff", func() {
+		limiter := newJitteredExponentialRateLimiter(4*time.Second, time.Minute)
+		limiter.jitter = func() float64 { return 0 }
+		Expect(limiter.When(item)).To(Equal(2 * time.Second))
+
+		limiter.jitter = func() float64 { return 0.5 }
+		Expect(limiter.When(item)).To(Equal(6 * time.Second))
+	})
+
+	It("should reset after a successful reconcile", func() {
+		limiter := newJitteredExponentialRateLimiter(time.Second, time.Minute)
+		limiter.jitter = func() float64 { return 1 }
+		limiter.When(item)
+		limiter.When(item)
+
+		limiter.Forget(item)
+		Expect(limiter.NumRequeues(item)).To(BeZero())
+		Expect(limiter.When(item)).To(Equal(time.Second))
+	})
+
+	It("should apply an item's retry policy over the limiter's delays", func() {
+		limiter := newJitteredExponentialRateLimiter(time.Second, time.Minute)
+		limiter.jitter = func() float64 { return 1 }
+		limiter.setPolicy(item, &RetryOptions{BaseDelay: 10 * time.Second, MaxDelay: 15 * time.Second})
+
+		Expect(limiter.When(item)).To(Equal(10 * time.Second))
+		Expect(limiter.When(item)).To(Equal(15 * time.Second))
+		Expect(limiter.When(other)).To(Equal(time.Second))
+
+		// Unset delays keep the limiter's
+		limiter.setPolicy(item, &RetryOptions{MaxDelay: 2 * time.Minute})
+		Expect(limiter.When(item)).To(Equal(4 * time.Second))
+
+		limiter.setPolicy(item, nil)
+		Expect(limiter.When(item)).To(Equal(8 * time.Second))
+	})
+
+	It("should fall back to the defaults for unset delays", func() {
+		limiter := newRetryRateLimiter(newRetryBackoff(RetryOptions{}))
+		delay := limiter.When(item)
+		Expect(delay).To(BeNumerically(">=", DefaultRetryBaseDelay/2))
+		Expect(delay).To(BeNumerically("<=", DefaultRetryBaseDelay))
+	})
+})
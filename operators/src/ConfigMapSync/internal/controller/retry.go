@@ -0,0 +1,128 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// defaultRecheckInterval is how soon a missing source or a blocked sync
+	// is checked again when spec.resyncInterval is unset.
+	defaultRecheckInterval = 5 * time.Minute
+
+	// reasonRetriesExhausted marks a sync that gave up after
+	// spec.retryPolicy.maxAttempts consecutive failures.
+	reasonRetriesExhausted = "RetriesExhausted"
+)
+
+// recheckInterval is how soon a sync that cannot proceed is checked again.
+func recheckInterval(configMapSync *appsv2.ConfigMapSync) time.Duration {
+	if interval := configMapSync.Spec.ResyncInterval; interval != nil && interval.Duration > 0 {
+		return interval.Duration
+	}
+	return defaultRecheckInterval
+}
+
+// resyncResult requeues a successful sync for its periodic verification,
+// if spec.resyncInterval asks for one.
+func resyncResult(configMapSync *appsv2.ConfigMapSync) ctrl.Result {
+	if interval := configMapSync.Spec.ResyncInterval; interval != nil && interval.Duration > 0 {
+		return ctrl.Result{RequeueAfter: interval.Duration}
+	}
+	return ctrl.Result{}
+}
+
+// retryOptions returns the delays of spec.retryPolicy; nil when it sets
+// none, so the operator's defaults apply.
+func retryOptions(configMapSync *appsv2.ConfigMapSync) *RetryOptions {
+	policy := configMapSync.Spec.RetryPolicy
+	if policy == nil || (policy.BaseDelay == nil && policy.MaxDelay == nil) {
+		return nil
+	}
+	options := &RetryOptions{}
+	if policy.BaseDelay != nil {
+		options.BaseDelay = policy.BaseDelay.Duration
+	}
+	if policy.MaxDelay != nil {
+		options.MaxDelay = policy.MaxDelay.Duration
+	}
+	return options
+}
+
+// exhaustedCondition returns the Stalled condition of a sync that gave up
+// retrying, or nil. Its observed generation is the spec it gave up on.
+func exhaustedCondition(configMapSync *appsv2.ConfigMapSync) *metav1.Condition {
+	stalled := meta.FindStatusCondition(configMapSync.Status.Conditions, TypeStalled)
+	if stalled == nil || stalled.Status != metav1.ConditionTrue || stalled.Reason != reasonRetriesExhausted {
+		return nil
+	}
+	return stalled
+}
+
+// failedAttempts counts the consecutive failed attempts of the sync,
+// including the current one. The workqueue's backoff keeps the count, which
+// survives failed status writes; status.retryCount only mirrors it. Without
+// a backoff, as in tests, the status count is used.
+func (r *ConfigMapSyncReconciler) failedAttempts(configMapSync *appsv2.ConfigMapSync) int {
+	if r.backoff == nil {
+		return configMapSync.Status.RetryCount + 1
+	}
+	return r.backoff.NumRequeues(reconcile.Request{NamespacedName: client.ObjectKeyFromObject(configMapSync)}) + 1
+}
+
+// exhaustRetries marks the sync Stalled with err once attempts reaches
+// spec.retryPolicy.maxAttempts and reports whether it did. The caller then
+// stops retrying.
+func (r *ConfigMapSyncReconciler) exhaustRetries(configMapSync *appsv2.ConfigMapSync, attempts int, err error) bool {
+	policy := configMapSync.Spec.RetryPolicy
+	if policy == nil || policy.MaxAttempts <= 0 || attempts < int(policy.MaxAttempts) {
+		return false
+	}
+	message := fmt.Sprintf("Gave up after %d failed attempts: %v", attempts, err)
+	configMapSync.Status.Message = message
+	r.markStalled(configMapSync, reasonRetriesExhausted, message)
+	r.recordEvent(configMapSync, nil, corev1.EventTypeWarning, EventReasonRetriesExhausted, message)
+	return true
+}
+
+// stopRetrying writes the status of a sync whose retries are exhausted. It
+// is not requeued; a spec change or a requested resync tries again.
+func (r *ConfigMapSyncReconciler) stopRetrying(ctx context.Context, configMapSync *appsv2.ConfigMapSync, originalStatus *appsv2.ConfigMapSyncStatus, err error) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.Info("Retries exhausted, giving up until the spec changes or a resync is requested",
+		"retryCount", configMapSync.Status.RetryCount,
+		"error", err.Error(),
+	)
+	if statusErr := r.updateStatus(ctx, configMapSync, originalStatus); statusErr != nil {
+		logger.Error(statusErr, "Failed to update ConfigMapSync status")
+		return ctrl.Result{}, statusErr
+	}
+	return ctrl.Result{}, nil
+}
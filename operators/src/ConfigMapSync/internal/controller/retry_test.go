@@ -0,0 +1,245 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+var _ = Describe("Resync intervals and retry policies", func() {
+	ctx := context.Background()
+	syncKey := types.NamespacedName{Name: "app-sync", Namespace: "team-a"}
+	request := ctrl.Request{NamespacedName: syncKey}
+
+	var (
+		reconciler *ConfigMapSyncReconciler
+		applies    int
+		failing    bool
+		failStatus bool
+	)
+
+	BeforeEach(func() {
+		configMapSync := newTestSync(func(spec *appsv2.ConfigMapSyncSpec) {
+			spec.RetryPolicy = &appsv2.RetryPolicy{
+				BaseDelay:   &metav1.Duration{Duration: 10 * time.Second},
+				MaxAttempts: 3,
+			}
+		})
+		configMapSync.Generation = 1
+		applies = 0
+		failing, failStatus = false, false
+		reconciler = newFakeReconciler(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				if patch.Type() == types.ApplyPatchType {
+					applies++
+					if failing {
+						return errors.New("etcdserver: request timed out")
+					}
+				}
+				return emulateApply(ctx, c, obj, patch, opts...)
+			},
+			SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				if failStatus {
+					return errors.New("etcdserver: leader changed")
+				}
+				return c.SubResource(subResourceName).Patch(ctx, obj, patch, opts...)
+			},
+		}, configMapSync)
+		reconciler.backoff = newRetryBackoff(RetryOptions{})
+	})
+
+	fetch := func() *appsv2.ConfigMapSync {
+		configMapSync := &appsv2.ConfigMapSync{}
+		Expect(reconciler.Get(ctx, syncKey, configMapSync)).To(Succeed())
+		return configMapSync
+	}
+
+	// reconcileQueued reconciles like the workqueue does, recording failures
+	// in the backoff and forgetting them after a success
+	reconcileQueued := func() (ctrl.Result, error) {
+		result, err := reconciler.Reconcile(ctx, request)
+		if err != nil {
+			reconciler.backoff.When(request)
+		} else {
+			reconciler.backoff.Forget(request)
+		}
+		return result, err
+	}
+
+	update := func(mutate func(*appsv2.ConfigMapSync)) {
+		configMapSync := fetch()
+		mutate(configMapSync)
+		Expect(reconciler.Update(ctx, configMapSync)).To(Succeed())
+	}
+
+	It("should requeue a synced ConfigMapSync at its resync interval", func() {
+		recorder := record.NewFakeRecorder(10)
+		reconciler.Recorder = recorder
+		result, err := reconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeZero())
+		Expect(recorder.Events).To(Receive(HavePrefix("Normal SyncCreated")))
+
+		update(func(configMapSync *appsv2.ConfigMapSync) {
+			configMapSync.Spec.ResyncInterval = &metav1.Duration{Duration: 30 * time.Minute}
+		})
+		result, err = reconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(30 * time.Minute))
+
+		// Verifying an up-to-date destination records no events
+		Expect(recorder.Events).NotTo(Receive())
+	})
+
+	It("should check a missing source again at the resync interval", func() {
+		update(func(configMapSync *appsv2.ConfigMapSync) {
+			configMapSync.Spec.Source.Name = "missing"
+		})
+		result, err := reconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(defaultRecheckInterval))
+
+		update(func(configMapSync *appsv2.ConfigMapSync) {
+			configMapSync.Spec.ResyncInterval = &metav1.Duration{Duration: time.Minute}
+		})
+		result, err = reconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(time.Minute))
+	})
+
+	It("should back off with the sync's retry policy", func() {
+		reconciler.backoff.jitter = func() float64 { return 1 }
+		_, err := reconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(reconciler.backoff.When(request)).To(Equal(10 * time.Second))
+	})
+
+	Context("when the retries run out", func() {
+		// exhaust fails every attempt until the sync gives up
+		exhaust := func() {
+			failing = true
+			for attempt := 1; attempt < 3; attempt++ {
+				_, err := reconcileQueued()
+				Expect(err).To(HaveOccurred())
+			}
+			_, err := reconcileQueued()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exhaustedCondition(fetch())).NotTo(BeNil())
+			failing = false
+		}
+
+		It("should stall after the maximum attempts and not try again", func() {
+			failing = true
+			_, err := reconcileQueued()
+			Expect(err).To(HaveOccurred())
+			Expect(fetch().Status.RetryCount).To(Equal(1))
+			_, err = reconcileQueued()
+			Expect(err).To(HaveOccurred())
+			Expect(fetch().Status.RetryCount).To(Equal(2))
+
+			// The last attempt gives up instead of returning the error
+			_, err = reconcileQueued()
+			Expect(err).NotTo(HaveOccurred())
+			stalled := meta.FindStatusCondition(fetch().Status.Conditions, TypeStalled)
+			Expect(stalled).NotTo(BeNil())
+			Expect(stalled.Reason).To(Equal(reasonRetriesExhausted))
+			Expect(stalled.Message).To(ContainSubstring("Gave up after 3 failed attempts"))
+			Expect(stalled.Message).To(ContainSubstring("request timed out"))
+
+			// Further reconciles do not try again
+			result, err := reconcileQueued()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+			Expect(applies).To(Equal(3))
+		})
+
+		It("should count an attempt whose status write was lost", func() {
+			failing = true
+			_, err := reconcileQueued()
+			Expect(err).To(HaveOccurred())
+			Expect(fetch().Status.RetryCount).To(Equal(1))
+
+			// Attempts are counted by the backoff, so a lost status write does
+			// not reset the count
+			failStatus = true
+			_, err = reconcileQueued()
+			Expect(err).To(HaveOccurred())
+			failStatus = false
+			Expect(fetch().Status.RetryCount).To(Equal(1))
+
+			_, err = reconcileQueued()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exhaustedCondition(fetch())).NotTo(BeNil())
+			Expect(applies).To(Equal(3))
+		})
+
+		It("should start a fresh round of attempts when a resync is requested", func() {
+			exhaust()
+			update(func(configMapSync *appsv2.ConfigMapSync) {
+				configMapSync.Annotations = map[string]string{ReconcileRequestAnnotation: "2026-10-18T12:00:00Z"}
+			})
+			_, err := reconcileQueued()
+			Expect(err).NotTo(HaveOccurred())
+			configMapSync := fetch()
+			Expect(meta.IsStatusConditionTrue(configMapSync.Status.Conditions, TypeReady)).To(BeTrue())
+			Expect(configMapSync.Status.RetryCount).To(BeZero())
+		})
+
+		It("should stay stalled when only the source changes", func() {
+			exhaust()
+			source := &corev1.ConfigMap{}
+			Expect(reconciler.Get(ctx, testSourceKey, source)).To(Succeed())
+			source.Data["key"] = "changed"
+			Expect(reconciler.Update(ctx, source)).To(Succeed())
+			result, err := reconcileQueued()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+			Expect(applies).To(Equal(3))
+			Expect(exhaustedCondition(fetch())).NotTo(BeNil())
+		})
+
+		It("should start a fresh round of attempts when the spec changes", func() {
+			exhaust()
+			update(func(configMapSync *appsv2.ConfigMapSync) {
+				configMapSync.Generation++
+				configMapSync.Spec.RetryPolicy.MaxAttempts = 5
+			})
+			_, err := reconcileQueued()
+			Expect(err).NotTo(HaveOccurred())
+			configMapSync := fetch()
+			Expect(meta.IsStatusConditionTrue(configMapSync.Status.Conditions, TypeReady)).To(BeTrue())
+			Expect(configMapSync.Status.RetryCount).To(BeZero())
+		})
+	})
+})
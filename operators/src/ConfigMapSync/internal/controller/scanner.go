@@ -0,0 +1,151 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// Tokens at least this long with at least this much Shannon entropy per
+	// character are treated as generated secrets.
+	highEntropyMinLength = 24
+	highEntropyThreshold = 4.2
+)
+
+// sensitivePatterns are well-known credential formats. Only the rule name
+// is ever reported; matched values must never leave the scanner.
+var sensitivePatterns = []struct {
+	rule    string
+	pattern *regexp.Regexp
+}{
+	{"private-key", regexp.MustCompile(`-----BEGIN ((RSA|EC|DSA|OPENSSH|ENCRYPTED|PGP) )?PRIVATE KEY( BLOCK)?-----`)},
+	{"aws-access-key-id", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"github-token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"gitlab-token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`)},
+	{"google-api-key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"stripe-key", regexp.MustCompile(`\b[sr]k_live_[0-9A-Za-z]{20,}\b`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{"password-in-url", regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:[^/\s:@]+@`)},
+}
+
+// tokenSeparator splits values into candidate tokens for the entropy check.
+// Characters that commonly appear inside base64, hex and URL-safe secrets
+// are kept together.
+var tokenSeparator = regexp.MustCompile(`[^A-Za-z0-9+/=_\-]+`)
+
+// publicPEMBlock matches certificates and public keys. CA bundles are common
+// in ConfigMaps and their base64 bodies would otherwise trip the entropy check.
+var publicPEMBlock = regexp.MustCompile(`(?s)-----BEGIN (CERTIFICATE|PUBLIC KEY|RSA PUBLIC KEY|X509 CRL|CERTIFICATE REQUEST)-----.*?-----END [A-Z0-9 ]+-----`)
+
+// sensitiveFinding records which key matched which rule. It deliberately
+// carries no part of the value.
+type sensitiveFinding struct {
+	Key  string
+	Rule string
+}
+
+// scanForSensitiveData checks every value that is about to be synced
+// against the known credential patterns and the entropy heuristic. Binary
+// values are scanned when they are valid UTF-8 text; other binary content
+// such as images cannot be matched meaningfully. Keys in allowlist are
+// skipped. Findings are sorted by key so
+// that status messages are stable between reconciles.
+func scanForSensitiveData(data map[string]string, binaryData map[string][]byte, allowlist []string) []sensitiveFinding {
+	allowed := make(map[string]bool, len(allowlist))
+	for _, key := range allowlist {
+		allowed[key] = true
+	}
+
+	var findings []sensitiveFinding
+	for key, value := range data {
+		if allowed[key] {
+			continue
+		}
+		if rule := matchSensitiveRule(value); rule != "" {
+			findings = append(findings, sensitiveFinding{Key: key, Rule: rule})
+		}
+	}
+	for key, value := range binaryData {
+		if allowed[key] || !utf8.Valid(value) {
+			continue
+		}
+		if rule := matchSensitiveRule(string(value)); rule != "" {
+			findings = append(findings, sensitiveFinding{Key: key, Rule: rule})
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].Key < findings[j].Key
+	})
+	return findings
+}
+
+// matchSensitiveRule returns the name of the first rule the value triggers,
+// or an empty string when the value looks clean.
+func matchSensitiveRule(value string) string {
+	for _, p := range sensitivePatterns {
+		if p.pattern.MatchString(value) {
+			return p.rule
+		}
+	}
+	for _, token := range tokenSeparator.Split(publicPEMBlock.ReplaceAllString(value, ""), -1) {
+		if len(token) >= highEntropyMinLength && shannonEntropy(token) >= highEntropyThreshold {
+			return "high-entropy-string"
+		}
+	}
+	return ""
+}
+
+// shannonEntropy returns the entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, c := range s {
+		counts[c]++
+	}
+	length := float64(len(s))
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// describeFindings renders findings as "key (rule)" pairs for conditions and
+// status messages.
+func describeFindings(findings []sensitiveFinding) string {
+	parts := make([]string, 0, len(findings))
+	for _, f := range findings {
+		parts = append(parts, fmt.Sprintf("%s (%s)", f.Key, f.Rule))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func findingKeys(findings []sensitiveFinding) []string {
+	keys := make([]string, 0, len(findings))
+	for _, f := range findings {
+		keys = append(keys, f.Key)
+	}
+	return keys
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+// TypeNamespacesInScope reports whether the sync only involves namespaces
+// the operator is restricted to.
+const TypeNamespacesInScope = "NamespacesInScope"
+
+// NamespaceScope restricts the operator to a set of namespaces, for
+// clusters that do not grant it cluster-wide access. The zero value allows
+// every namespace.
+type NamespaceScope struct {
+	// Watch are the namespaces whose ConfigMapSyncs are reconciled and that
+	// destinations are written to. Empty means all namespaces.
+	Watch []string
+	// Sources are further namespaces that sources may only be read from.
+	Sources []string
+}
+
+// Restricted reports whether the operator is limited to some namespaces.
+func (s NamespaceScope) Restricted() bool {
+	return len(s.Watch) > 0 || len(s.Sources) > 0
+}
+
+// Validate rejects source namespaces without watched namespaces. The cache
+// would then have to watch every namespace for ConfigMapSyncs, so the
+// restriction could not be enforced on what the operator reads.
+func (s NamespaceScope) Validate() error {
+	if len(s.Sources) > 0 && len(s.Watch) == 0 {
+		return fmt.Errorf("source namespaces %s need watched namespaces as well", strings.Join(s.Sources, ","))
+	}
+	return nil
+}
+
+// CacheNamespaces returns the namespaces for the manager cache's
+// DefaultNamespaces, or nil when ConfigMapSyncs are watched cluster-wide.
+func (s NamespaceScope) CacheNamespaces() map[string]cache.Config {
+	if len(s.Watch) == 0 {
+		return nil
+	}
+	namespaces := map[string]cache.Config{}
+	for _, namespace := range slices.Concat(s.Watch, s.Sources) {
+		namespaces[namespace] = cache.Config{}
+	}
+	return namespaces
+}
+
+// Namespaces returns every namespace in the scope, sorted.
+func (s NamespaceScope) Namespaces() []string {
+	namespaces := slices.Concat(s.Watch, s.Sources)
+	slices.Sort(namespaces)
+	return slices.Compact(namespaces)
+}
+
+// violations describes each namespace of configMapSync that lies outside
+// the scope. It is empty when the sync is allowed.
+func (s NamespaceScope) violations(configMapSync *appsv2.ConfigMapSync) []string {
+	var violations []string
+	if len(s.Watch) > 0 && !slices.Contains(s.Watch, configMapSync.Namespace) {
+		violations = append(violations, fmt.Sprintf("ConfigMapSync namespace %s is not watched", configMapSync.Namespace))
+	}
+	source := configMapSync.Spec.Source.Namespace
+	if s.Restricted() && !slices.Contains(s.Watch, source) && !slices.Contains(s.Sources, source) {
+		violations = append(violations, fmt.Sprintf("source namespace %s is not a watched or source namespace", source))
+	}
+	if len(s.Watch) > 0 {
+		for _, destination := range destinationKeys(configMapSync) {
+			violation := fmt.Sprintf("destination namespace %s is not watched", destination.Namespace)
+			if !slices.Contains(s.Watch, destination.Namespace) && !slices.Contains(violations, violation) {
+				violations = append(violations, violation)
+			}
+		}
+	}
+	return violations
+}
+
+// ParseNamespaces splits a comma-separated list of namespaces, dropping
+// blanks and duplicates.
+func ParseNamespaces(list string) []string {
+	var namespaces []string
+	for _, namespace := range strings.Split(list, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if namespace != "" && !slices.Contains(namespaces, namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
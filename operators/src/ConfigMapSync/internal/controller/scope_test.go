@@ -0,0 +1,94 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+var _ = Describe("Namespace scope", func() {
+	ctx := context.Background()
+	scope := NamespaceScope{Watch: []string{"team-a", "tenant-1"}, Sources: []string{"shared"}}
+
+	newSync := func(source string, destinations ...string) *appsv2.ConfigMapSync {
+		configMapSync := &appsv2.ConfigMapSync{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-sync", Namespace: "team-a"},
+			Spec:       appsv2.ConfigMapSyncSpec{Source: appsv2.SourceReference{Namespace: source, Name: "app-config"}},
+		}
+		for _, destination := range destinations {
+			configMapSync.Spec.Destinations = append(configMapSync.Spec.Destinations, appsv2.DestinationReference{Namespace: destination})
+		}
+		return configMapSync
+	}
+
+	It("should allow sources from source namespaces and destinations in watched ones", func() {
+		Expect(scope.violations(newSync("shared", "tenant-1"))).To(BeEmpty())
+		Expect(scope.violations(newSync("team-a", "team-a"))).To(BeEmpty())
+	})
+
+	It("should describe every namespace outside the scope", func() {
+		Expect(scope.violations(newSync("other", "shared", "tenant-2"))).To(Equal([]string{
+			"source namespace other is not a watched or source namespace",
+			"destination namespace shared is not watched",
+			"destination namespace tenant-2 is not watched",
+		}))
+		Expect(NamespaceScope{}.violations(newSync("other", "tenant-2"))).To(BeEmpty())
+	})
+
+	It("should reject source namespaces without watched namespaces", func() {
+		Expect(NamespaceScope{Sources: []string{"shared"}}.Validate()).To(MatchError(ContainSubstring("need watched namespaces")))
+		Expect(scope.Validate()).To(Succeed())
+		Expect(NamespaceScope{}.Validate()).To(Succeed())
+	})
+
+	It("should cache the watched and source namespaces", func() {
+		Expect(scope.CacheNamespaces()).To(HaveLen(3))
+		Expect(NamespaceScope{}.CacheNamespaces()).To(BeNil())
+		Expect(ParseNamespaces(" team-a,,tenant-1,team-a ")).To(Equal([]string{"team-a", "tenant-1"}))
+	})
+
+	It("should stall a sync that reaches outside the scope", func() {
+		configMapSync := newTestSync(func(spec *appsv2.ConfigMapSyncSpec) {
+			spec.Destinations = []appsv2.DestinationReference{{Namespace: "tenant-2"}}
+		})
+		reconciler := newFakeReconciler(interceptor.Funcs{}, configMapSync)
+		reconciler.Namespaces = scope
+
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(configMapSync)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(5 * time.Minute))
+
+		Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(configMapSync), configMapSync)).To(Succeed())
+		inScope := meta.FindStatusCondition(configMapSync.Status.Conditions, TypeNamespacesInScope)
+		Expect(inScope).NotTo(BeNil())
+		Expect(inScope.Status).To(Equal(metav1.ConditionFalse))
+		Expect(inScope.Message).To(ContainSubstring("destination namespace tenant-2 is not watched"))
+		Expect(meta.FindStatusCondition(configMapSync.Status.Conditions, TypeStalled).Reason).To(Equal("NamespaceOutOfScope"))
+	})
+})
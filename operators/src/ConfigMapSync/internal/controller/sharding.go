@@ -0,0 +1,581 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+// ShardLabel records the shard a ConfigMapSync belongs to. It is written by
+// the replica that owns the shard.
+const ShardLabel = "configmapsync.apps.kapendra.com/shard"
+
+// Default timings of the shard leases.
+const (
+	DefaultShardLeaseDuration = 15 * time.Second
+	DefaultShardRenewPeriod   = 5 * time.Second
+)
+
+// Shard and member leases are told apart by the value of shardLeaseLabel.
+const (
+	shardLeaseLabel   = "configmapsync.apps.kapendra.com/lease"
+	shardLeaseKind    = "shard"
+	memberLeaseKind   = "member"
+	shardLeasePrefix  = "configmapsync-shard-"
+	memberLeasePrefix = "configmapsync-member-"
+)
+
+// ShardOptions configures horizontal sharding. ConfigMapSyncs are split into
+// Shards by a hash of their namespace, and each shard is processed by the
+// replica holding its Lease.
+type ShardOptions struct {
+	// Shards is the number of shards. It must be the same on every replica.
+	Shards int
+	// Identity names this replica in the leases. It must be unique.
+	Identity string
+	// Namespace holds the shard and member leases.
+	Namespace string
+	// LeaseDuration is how long a lease stays valid without renewal. A dead
+	// replica's shards are taken over within LeaseDuration plus RenewPeriod.
+	LeaseDuration time.Duration
+	// RenewPeriod is how often leases are renewed and rebalanced.
+	RenewPeriod time.Duration
+}
+
+// ShardManager holds this replica's share of the shard leases. Every
+// replica renews a member lease; the live members split the shards evenly
+// between them, so shards are handed over as replicas join and leave.
+//
+// A replica only reconciles ConfigMapSyncs of shards whose lease it holds
+// and has renewed within the lease duration. A shard that is handed over is
+// first drained: it stops admitting reconciles and its lease is released
+// once the reconciles already running have finished. A shard that is lost
+// instead, because its lease lapsed or was taken, cancels the reconciles
+// still running in it.
+//
+// The leases of other replicas are never compared against the local clock.
+// Like client-go's leader election, a replica notes the local time it last
+// saw a lease's holder or renew time change, and treats the lease as expired
+// once it has stayed unchanged for its duration since then.
+type ShardManager struct {
+	client    client.Client
+	apiReader client.Reader
+	options   ShardOptions
+	now       func() time.Time
+
+	// events enqueues the ConfigMapSyncs of newly acquired shards.
+	events chan event.GenericEvent
+
+	// observed records when each listed lease was last seen to change. It
+	// is only used by rebalance.
+	observed map[string]leaseObservation
+
+	mu       sync.Mutex
+	owned    map[int]*shardTenure
+	draining map[int]bool
+	inFlight map[int]int
+}
+
+// shardTenure is this replica's hold on a shard.
+type shardTenure struct {
+	// expiry is the local time the lease expires unless renewed.
+	expiry time.Time
+	// ctx is cancelled once the shard is lost, which cancels the
+	// reconciles admitted in it.
+	ctx    context.Context
+	cancel context.CancelFunc
+	// lapse cancels ctx once expiry passes.
+	lapse *time.Timer
+}
+
+// leaseObservation is a lease as last seen by this replica.
+type leaseObservation struct {
+	holder     string
+	renewTime  metav1.MicroTime
+	observedAt time.Time
+}
+
+// NewShardManager returns a ShardManager that writes leases with c and
+// reads them with apiReader, which should bypass the cache so that only the
+// lease namespace needs to be readable.
+func NewShardManager(c client.Client, apiReader client.Reader, options ShardOptions) *ShardManager {
+	if options.LeaseDuration <= 0 {
+		options.LeaseDuration = DefaultShardLeaseDuration
+	}
+	if options.RenewPeriod <= 0 || options.RenewPeriod >= options.LeaseDuration {
+		options.RenewPeriod = options.LeaseDuration / 3
+	}
+	return &ShardManager{
+		client:    c,
+		apiReader: apiReader,
+		options:   options,
+		now:       time.Now,
+		events:    make(chan event.GenericEvent, 1024),
+		observed:  map[string]leaseObservation{},
+		owned:     map[int]*shardTenure{},
+		draining:  map[int]bool{},
+		inFlight:  map[int]int{},
+	}
+}
+
+// shardFor returns the shard of ConfigMapSyncs in namespace.
+func shardFor(namespace string, shards int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(namespace))
+	return int(h.Sum32() % uint32(shards))
+}
+
+// ShardFor returns the shard of ConfigMapSyncs in namespace.
+func (m *ShardManager) ShardFor(namespace string) int {
+	return shardFor(namespace, m.options.Shards)
+}
+
+// Begin admits a reconcile of a ConfigMapSync in namespace. It reports false
+// when this replica does not own the namespace's shard; otherwise the
+// reconcile must run with the returned context, which is cancelled if the
+// shard is lost before the reconcile finishes, and done must be called once
+// it has finished.
+func (m *ShardManager) Begin(ctx context.Context, namespace string) (_ context.Context, done func(), ok bool) {
+	shard := m.ShardFor(namespace)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tenure, held := m.owned[shard]
+	if !held || m.draining[shard] || !m.now().Before(tenure.expiry) {
+		return ctx, nil, false
+	}
+	m.inFlight[shard]++
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(tenure.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.inFlight[shard]--
+	}, true
+}
+
+// Owned returns the shards this replica currently processes.
+func (m *ShardManager) Owned() []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var shards []int
+	now := m.now()
+	for shard, tenure := range m.owned {
+		if !m.draining[shard] && now.Before(tenure.expiry) {
+			shards = append(shards, shard)
+		}
+	}
+	slices.Sort(shards)
+	return shards
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every
+// replica takes part in sharding.
+func (m *ShardManager) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements manager.Runnable. It renews and rebalances the leases
+// every RenewPeriod and releases them on shutdown so that other replicas
+// can take over without waiting for them to expire.
+func (m *ShardManager) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("sharding")
+	ctx = log.IntoContext(ctx, logger)
+	logger.Info("Starting shard manager", "identity", m.options.Identity, "shards", m.options.Shards)
+
+	ticker := time.NewTicker(m.options.RenewPeriod)
+	defer ticker.Stop()
+	for {
+		if err := m.rebalance(ctx); err != nil {
+			logger.Error(err, "Failed to rebalance shards")
+		}
+		select {
+		case <-ctx.Done():
+			m.shutdown(log.IntoContext(context.Background(), logger))
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// rebalance renews this replica's leases, hands over the shards above its
+// share and acquires free shards below it.
+func (m *ShardManager) rebalance(ctx context.Context) error {
+	logger := log.FromContext(ctx)
+	now := m.now()
+
+	if err := m.heartbeat(ctx, now); err != nil {
+		return fmt.Errorf("renewing member lease: %w", err)
+	}
+	leases := &coordinationv1.LeaseList{}
+	if err := m.apiReader.List(ctx, leases, client.InNamespace(m.options.Namespace), client.HasLabels{shardLeaseLabel}); err != nil {
+		return fmt.Errorf("listing shard leases: %w", err)
+	}
+
+	m.observe(leases.Items, now)
+
+	var members []string
+	shardLeases := map[int]*coordinationv1.Lease{}
+	for i := range leases.Items {
+		lease := &leases.Items[i]
+		switch lease.Labels[shardLeaseLabel] {
+		case memberLeaseKind:
+			if !m.leaseExpired(lease, now) {
+				members = append(members, *lease.Spec.HolderIdentity)
+			}
+		case shardLeaseKind:
+			if shard, err := strconv.Atoi(strings.TrimPrefix(lease.Name, shardLeasePrefix)); err == nil && shard < m.options.Shards {
+				shardLeases[shard] = lease
+			}
+		}
+	}
+	share := shardShare(m.options.Shards, members, m.options.Identity)
+
+	// Renew what we hold, keeping the lowest shards up to our share. Shards
+	// whose lease another replica took meanwhile are lost.
+	var held []int
+	for shard := range m.options.Shards {
+		if lease := shardLeases[shard]; lease != nil && holdsLease(lease, m.options.Identity) {
+			held = append(held, shard)
+		} else if m.holds(shard) {
+			logger.Info("Lost shard", "shard", shard)
+			m.forget(shard)
+		}
+	}
+	for i, shard := range held {
+		if i >= share && m.drained(shard) {
+			if err := m.release(ctx, shardLeases[shard]); err != nil {
+				logger.Error(err, "Failed to release shard", "shard", shard)
+			} else {
+				logger.Info("Released shard", "shard", shard)
+			}
+			continue
+		}
+		if i < share {
+			m.resume(shard)
+		}
+		if err := m.renew(ctx, shardLeases[shard], shard, now); err != nil {
+			logger.Error(err, "Failed to renew shard lease", "shard", shard)
+			if apierrors.IsConflict(err) {
+				m.forget(shard)
+			}
+		}
+	}
+
+	// Acquire free shards while below our share
+	count := min(len(held), share)
+	for shard := range m.options.Shards {
+		if count >= share {
+			break
+		}
+		lease := shardLeases[shard]
+		if lease != nil && (holdsLease(lease, m.options.Identity) || !m.leaseExpired(lease, now)) {
+			continue
+		}
+		if err := m.acquire(ctx, lease, shard, now); err != nil {
+			if !apierrors.IsConflict(err) && !apierrors.IsAlreadyExists(err) {
+				logger.Error(err, "Failed to acquire shard", "shard", shard)
+			}
+			continue
+		}
+		logger.Info("Acquired shard", "shard", shard)
+		count++
+		go m.enqueueShard(ctx, shard)
+	}
+	shardsOwned.Set(float64(len(m.Owned())))
+	return nil
+}
+
+// shardShare returns how many of shards identity should hold: the shards
+// are split evenly between the sorted live members, the first ones taking
+// one more when they do not divide evenly.
+func shardShare(shards int, members []string, identity string) int {
+	slices.Sort(members)
+	index, found := slices.BinarySearch(members, identity)
+	if !found {
+		return 0
+	}
+	share := shards / len(members)
+	if index < shards%len(members) {
+		share++
+	}
+	return share
+}
+
+// heartbeat renews this replica's member lease.
+func (m *ShardManager) heartbeat(ctx context.Context, now time.Time) error {
+	lease := &coordinationv1.Lease{}
+	key := client.ObjectKey{Namespace: m.options.Namespace, Name: memberLeasePrefix + m.options.Identity}
+	if err := m.apiReader.Get(ctx, key, lease); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		lease.Name, lease.Namespace = key.Name, key.Namespace
+		lease.Labels = map[string]string{shardLeaseLabel: memberLeaseKind}
+		m.stamp(lease, now, true)
+		return m.client.Create(ctx, lease)
+	}
+	m.stamp(lease, now, lease.Spec.HolderIdentity == nil)
+	return m.client.Update(ctx, lease)
+}
+
+// acquire takes the shard's lease, creating it when it does not exist. The
+// update is rejected with a conflict when another replica was faster.
+func (m *ShardManager) acquire(ctx context.Context, lease *coordinationv1.Lease, shard int, now time.Time) error {
+	if lease == nil {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      shardLeasePrefix + strconv.Itoa(shard),
+				Namespace: m.options.Namespace,
+				Labels:    map[string]string{shardLeaseLabel: shardLeaseKind},
+			},
+		}
+		m.stamp(lease, now, true)
+		if err := m.client.Create(ctx, lease); err != nil {
+			return err
+		}
+	} else {
+		lease = lease.DeepCopy()
+		lease.Spec.LeaseTransitions = ptr.To(ptr.Deref(lease.Spec.LeaseTransitions, 0) + 1)
+		m.stamp(lease, now, true)
+		if err := m.client.Update(ctx, lease); err != nil {
+			return err
+		}
+	}
+	m.extend(shard, now)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.draining, shard)
+	return nil
+}
+
+// renew extends a held shard lease. The local expiry is measured from the
+// time the renewal was sent, so it never outlasts the lease as others see it.
+func (m *ShardManager) renew(ctx context.Context, lease *coordinationv1.Lease, shard int, now time.Time) error {
+	lease = lease.DeepCopy()
+	m.stamp(lease, now, false)
+	if err := m.client.Update(ctx, lease); err != nil {
+		return err
+	}
+	m.extend(shard, now)
+	return nil
+}
+
+// extend records that this replica holds shard until one lease duration
+// after now, when the shard is lost unless extended again.
+func (m *ShardManager) extend(shard int, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tenure := m.owned[shard]
+	if tenure == nil {
+		tenure = &shardTenure{}
+		tenure.ctx, tenure.cancel = context.WithCancel(context.Background())
+		tenure.lapse = time.AfterFunc(m.options.LeaseDuration, func() { m.lapse(shard, tenure) })
+		m.owned[shard] = tenure
+	} else {
+		tenure.lapse.Reset(m.options.LeaseDuration)
+	}
+	tenure.expiry = now.Add(m.options.LeaseDuration)
+}
+
+// lapse drops tenure of shard if it has expired without being extended.
+func (m *ShardManager) lapse(shard int, tenure *shardTenure) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.owned[shard] != tenure {
+		return
+	}
+	if remaining := tenure.expiry.Sub(m.now()); remaining > 0 {
+		tenure.lapse.Reset(remaining)
+		return
+	}
+	m.drop(shard)
+}
+
+// holds reports whether this replica has a tenure of shard, expired or not.
+func (m *ShardManager) holds(shard int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, held := m.owned[shard]
+	return held
+}
+
+// release gives up a shard lease so another replica can acquire it at once.
+func (m *ShardManager) release(ctx context.Context, lease *coordinationv1.Lease) error {
+	lease = lease.DeepCopy()
+	lease.Spec.HolderIdentity = nil
+	lease.Spec.RenewTime = nil
+	lease.Spec.AcquireTime = nil
+	if err := m.client.Update(ctx, lease); err != nil {
+		return err
+	}
+	shard, _ := strconv.Atoi(strings.TrimPrefix(lease.Name, shardLeasePrefix))
+	m.forget(shard)
+	return nil
+}
+
+// stamp marks lease as held by this replica at now.
+func (m *ShardManager) stamp(lease *coordinationv1.Lease, now time.Time, acquired bool) {
+	lease.Spec.HolderIdentity = ptr.To(m.options.Identity)
+	lease.Spec.LeaseDurationSeconds = ptr.To(int32(m.options.LeaseDuration / time.Second))
+	lease.Spec.RenewTime = ptr.To(metav1.NewMicroTime(now))
+	if acquired {
+		lease.Spec.AcquireTime = ptr.To(metav1.NewMicroTime(now))
+	}
+}
+
+// drained stops admitting reconciles for shard and reports whether the
+// reconciles already running have finished.
+func (m *ShardManager) drained(shard int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.draining[shard] = true
+	return m.inFlight[shard] == 0
+}
+
+// resume admits reconciles for a shard that no longer needs to be handed over.
+func (m *ShardManager) resume(shard int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.draining, shard)
+}
+
+// forget drops shard from the shards this replica processes.
+func (m *ShardManager) forget(shard int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.drop(shard)
+}
+
+// drop ends the tenure of shard, cancelling the reconciles running in it.
+// m.mu must be held.
+func (m *ShardManager) drop(shard int) {
+	if tenure := m.owned[shard]; tenure != nil {
+		tenure.lapse.Stop()
+		tenure.cancel()
+	}
+	delete(m.owned, shard)
+	delete(m.draining, shard)
+}
+
+// shutdown drains every held shard and releases its lease. It gives up
+// after one lease duration, at which point the leases expire anyway.
+func (m *ShardManager) shutdown(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, m.options.LeaseDuration)
+	defer cancel()
+	logger := log.FromContext(ctx)
+
+	held := m.Owned()
+	for _, shard := range held {
+		m.drained(shard)
+	}
+	for _, shard := range held {
+		for !m.drained(shard) {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+		lease := &coordinationv1.Lease{}
+		key := client.ObjectKey{Namespace: m.options.Namespace, Name: shardLeasePrefix + strconv.Itoa(shard)}
+		if err := m.apiReader.Get(ctx, key, lease); err != nil || !holdsLease(lease, m.options.Identity) {
+			continue
+		}
+		if err := m.release(ctx, lease); err != nil {
+			logger.Error(err, "Failed to release shard", "shard", shard)
+		}
+	}
+	shardsOwned.Set(0)
+}
+
+// enqueueShard requests a reconcile of every ConfigMapSync in shard, which
+// other replicas may have changed while this one did not own it.
+func (m *ShardManager) enqueueShard(ctx context.Context, shard int) {
+	syncs := &appsv2.ConfigMapSyncList{}
+	if err := m.client.List(ctx, syncs); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list ConfigMapSyncs of acquired shard", "shard", shard)
+		return
+	}
+	for i := range syncs.Items {
+		if m.ShardFor(syncs.Items[i].Namespace) != shard {
+			continue
+		}
+		select {
+		case m.events <- event.GenericEvent{Object: &syncs.Items[i]}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// holdsLease reports whether lease names identity as its holder.
+func holdsLease(lease *coordinationv1.Lease, identity string) bool {
+	return ptr.Deref(lease.Spec.HolderIdentity, "") == identity
+}
+
+// observe records the local time each of leases was first seen with its
+// current holder and renew time, and forgets leases that no longer exist.
+func (m *ShardManager) observe(leases []coordinationv1.Lease, now time.Time) {
+	observed := make(map[string]leaseObservation, len(leases))
+	for i := range leases {
+		lease := &leases[i]
+		current := leaseObservation{holder: ptr.Deref(lease.Spec.HolderIdentity, ""), observedAt: now}
+		if lease.Spec.RenewTime != nil {
+			current.renewTime = *lease.Spec.RenewTime
+		}
+		if previous, ok := m.observed[lease.Name]; ok && previous.holder == current.holder && previous.renewTime.Equal(&current.renewTime) {
+			current.observedAt = previous.observedAt
+		}
+		observed[lease.Name] = current
+	}
+	m.observed = observed
+}
+
+// leaseExpired reports whether lease is free or has not been renewed for
+// its duration since this replica first observed its current renewal. The
+// holder's renew time is only compared for changes, so clock skew between
+// replicas cannot expire a live lease.
+func (m *ShardManager) leaseExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	if ptr.Deref(lease.Spec.HolderIdentity, "") == "" || lease.Spec.RenewTime == nil {
+		return true
+	}
+	observation, ok := m.observed[lease.Name]
+	if !ok {
+		return false
+	}
+	duration := time.Duration(ptr.Deref(lease.Spec.LeaseDurationSeconds, 0)) * time.Second
+	return !now.Before(observation.observedAt.Add(duration))
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+var _ = Describe("Sharding", func() {
+	ctx := context.Background()
+	const shards = 4
+
+	var (
+		c          client.Client
+		reconciler *ConfigMapSyncReconciler
+		clock      time.Time
+	)
+
+	BeforeEach(func() {
+		// The ConfigMapSync is not finalized yet, which shows whether a
+		// reconcile got past the shard check
+		configMapSync := newTestSync(nil)
+		configMapSync.Finalizers = nil
+		reconciler = newFakeReconciler(interceptor.Funcs{}, configMapSync)
+		c = reconciler.Client
+		clock = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	})
+
+	replica := func(identity string) *ShardManager {
+		m := NewShardManager(c, c, ShardOptions{
+			Shards:        shards,
+			Identity:      identity,
+			Namespace:     "configmapsync-system",
+			LeaseDuration: 15 * time.Second,
+		})
+		m.now = func() time.Time { return clock }
+		return m
+	}
+
+	// expectDisjoint checks that no shard is processed by two replicas.
+	expectDisjoint := func(replicas ...*ShardManager) {
+		seen := map[int]bool{}
+		for _, m := range replicas {
+			for _, shard := range m.Owned() {
+				Expect(seen).NotTo(HaveKey(shard))
+				seen[shard] = true
+			}
+		}
+	}
+
+	It("should rebalance when a replica joins and take over when it dies", func() {
+		first := replica("replica-a")
+		Expect(first.rebalance(ctx)).To(Succeed())
+		Expect(first.Owned()).To(Equal([]int{0, 1, 2, 3}))
+
+		// The new replica waits for the shards to be handed over
+		second := replica("replica-b")
+		Expect(second.rebalance(ctx)).To(Succeed())
+		Expect(second.Owned()).To(BeEmpty())
+		expectDisjoint(first, second)
+
+		clock = clock.Add(5 * time.Second)
+		Expect(first.rebalance(ctx)).To(Succeed())
+		Expect(first.Owned()).To(Equal([]int{0, 1}))
+		Expect(second.rebalance(ctx)).To(Succeed())
+		Expect(second.Owned()).To(Equal([]int{2, 3}))
+		expectDisjoint(first, second)
+
+		// The first replica stops renewing; its shards expire and move over
+		clock = clock.Add(10 * time.Second)
+		Expect(second.rebalance(ctx)).To(Succeed())
+		Expect(second.Owned()).To(Equal([]int{2, 3}))
+
+		clock = clock.Add(6 * time.Second)
+		Expect(first.Owned()).To(BeEmpty(), "a replica stops processing once its leases may have expired")
+		Expect(second.rebalance(ctx)).To(Succeed())
+		Expect(second.Owned()).To(Equal([]int{0, 1, 2, 3}))
+	})
+
+	It("should only hand over a shard once its running reconciles finish", func() {
+		first := replica("replica-a")
+		Expect(first.rebalance(ctx)).To(Succeed())
+
+		var namespace string
+		for _, candidate := range []string{"team-a", "team-b", "team-c", "team-d", "team-e", "team-f"} {
+			if first.ShardFor(candidate) >= 2 {
+				namespace = candidate
+				break
+			}
+		}
+		Expect(namespace).NotTo(BeEmpty())
+		_, done, ok := first.Begin(ctx, namespace)
+		Expect(ok).To(BeTrue())
+
+		second := replica("replica-b")
+		Expect(second.rebalance(ctx)).To(Succeed())
+		clock = clock.Add(5 * time.Second)
+		Expect(first.rebalance(ctx)).To(Succeed())
+		_, _, ok = first.Begin(ctx, namespace)
+		Expect(ok).To(BeFalse(), "a draining shard admits no new reconciles")
+
+		Expect(second.rebalance(ctx)).To(Succeed())
+		Expect(second.Owned()).NotTo(ContainElement(first.ShardFor(namespace)))
+
+		done()
+		Expect(first.rebalance(ctx)).To(Succeed())
+		Expect(second.rebalance(ctx)).To(Succeed())
+		Expect(second.Owned()).To(ContainElement(first.ShardFor(namespace)))
+		expectDisjoint(first, second)
+	})
+
+	It("should measure the expiry of other replicas' leases on its own clock", func() {
+		first := replica("replica-a")
+		Expect(first.rebalance(ctx)).To(Succeed())
+
+		// A replica whose clock runs a minute ahead sees renewals that look
+		// long expired, but they keep changing
+		ahead := replica("replica-b")
+		ahead.now = func() time.Time { return clock.Add(time.Minute) }
+		for range 4 {
+			Expect(ahead.rebalance(ctx)).To(Succeed())
+			expectDisjoint(first, ahead)
+			clock = clock.Add(5 * time.Second)
+			Expect(first.rebalance(ctx)).To(Succeed())
+			Expect(first.Owned()).To(Equal([]int{0, 1}))
+			expectDisjoint(first, ahead)
+		}
+		Expect(ahead.rebalance(ctx)).To(Succeed())
+		Expect(ahead.Owned()).To(Equal([]int{2, 3}))
+
+		// Once the renewals stop, it waits a full lease duration of its own
+		// from when it saw the last one
+		clock = clock.Add(10 * time.Second)
+		Expect(ahead.rebalance(ctx)).To(Succeed())
+		Expect(ahead.Owned()).To(Equal([]int{2, 3}))
+		clock = clock.Add(6 * time.Second)
+		Expect(ahead.rebalance(ctx)).To(Succeed())
+		Expect(ahead.Owned()).To(Equal([]int{0, 1, 2, 3}))
+	})
+
+	It("should cancel running reconciles of a shard it loses", func() {
+		first := replica("replica-a")
+		Expect(first.rebalance(ctx)).To(Succeed())
+		reconcileCtx, done, ok := first.Begin(ctx, "team-a")
+		Expect(ok).To(BeTrue())
+		defer done()
+
+		// The lease lapses while the replica cannot renew it and another
+		// replica takes the shard
+		clock = clock.Add(16 * time.Second)
+		second := replica("replica-b")
+		Expect(second.rebalance(ctx)).To(Succeed())
+		clock = clock.Add(15 * time.Second)
+		Expect(second.rebalance(ctx)).To(Succeed())
+		Expect(second.Owned()).To(ContainElement(second.ShardFor("team-a")))
+		Expect(reconcileCtx.Err()).NotTo(HaveOccurred(), "the replica has not noticed yet")
+
+		Expect(first.rebalance(ctx)).To(Succeed())
+		Eventually(reconcileCtx.Done()).Should(BeClosed())
+		expectDisjoint(first, second)
+	})
+
+	It("should only reconcile and label ConfigMapSyncs of owned shards", func() {
+		m := replica("replica-a")
+		reconciler.Shards = m
+		configMapSync := &appsv2.ConfigMapSync{}
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: testSyncKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, testSyncKey, configMapSync)).To(Succeed())
+		Expect(configMapSync.Finalizers).To(BeEmpty(), "no shard is held yet")
+
+		Expect(m.rebalance(ctx)).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: testSyncKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, testSyncKey, configMapSync)).To(Succeed())
+		Expect(configMapSync.Finalizers).To(ContainElement(ConfigMapSyncFinalizer))
+		Expect(configMapSync.Labels).To(HaveKeyWithValue(ShardLabel, strconv.Itoa(m.ShardFor("team-a"))))
+	})
+
+	It("should split shards evenly between the live replicas", func() {
+		members := []string{"c", "a", "b"}
+		Expect(shardShare(4, members, "a")).To(Equal(2))
+		Expect(shardShare(4, members, "b")).To(Equal(1))
+		Expect(shardShare(4, members, "c")).To(Equal(1))
+		Expect(shardShare(4, members, "d")).To(BeZero())
+	})
+})
@@ -0,0 +1,217 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// SignatureAnnotation holds the base64-encoded detached signature over the
+	// canonical encoding of the source ConfigMap's Data and BinaryData.
+	SignatureAnnotation = "configmapsync.apps.kapendra.com/signature"
+
+	// signaturePayloadHeader versions the canonical encoding that is signed.
+	signaturePayloadHeader = "configmapsync-signature-v1\n"
+)
+
+var (
+	errUnsigned           = errors.New("source ConfigMap is not signed")
+	errInvalidSignature   = errors.New("source ConfigMap signature does not match any trusted key")
+	errNoTrustedKeys      = errors.New("no trusted public keys found")
+	errMalformedSignature = errors.New("signature annotation is not valid base64")
+)
+
+// canonicalSourceContent returns the bytes that the release pipeline signs.
+// Data and BinaryData are written in that order, each as an entry count
+// followed by its entries sorted by key. Every key and value is prefixed
+// with its length as a big-endian uint32, so no two different maps share an
+// encoding.
+func canonicalSourceContent(source *corev1.ConfigMap) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(signaturePayloadHeader)
+	writeStringMap(&buf, source.Data)
+	writeBytesMap(&buf, source.BinaryData)
+	return buf.Bytes()
+}
+
+// writeStringMap writes the entry count of m followed by its length-prefixed
+// keys and values in key order.
+func writeStringMap(buf *bytes.Buffer, m map[string]string) {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	writeLength(buf, len(keys))
+	for _, key := range keys {
+		writeLengthPrefixed(buf, []byte(key))
+		writeLengthPrefixed(buf, []byte(m[key]))
+	}
+}
+
+// writeBytesMap is writeStringMap for binary values.
+func writeBytesMap(buf *bytes.Buffer, m map[string][]byte) {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	writeLength(buf, len(keys))
+	for _, key := range keys {
+		writeLengthPrefixed(buf, []byte(key))
+		writeLengthPrefixed(buf, m[key])
+	}
+}
+
+func writeLength(buf *bytes.Buffer, n int) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(n))
+	buf.Write(length[:])
+}
+
+func writeLengthPrefixed(buf *bytes.Buffer, b []byte) {
+	writeLength(buf, len(b))
+	buf.Write(b)
+}
+
+// verifySourceSignature checks the signature annotation on the source against
+// each trusted key and succeeds if any of them verifies it.
+func verifySourceSignature(source *corev1.ConfigMap, trustedKeys []crypto.PublicKey) error {
+	encoded, ok := source.Annotations[SignatureAnnotation]
+	if !ok || strings.TrimSpace(encoded) == "" {
+		return errUnsigned
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return errMalformedSignature
+	}
+
+	payload := canonicalSourceContent(source)
+	for _, key := range trustedKeys {
+		if verifyWithKey(key, payload, signature) {
+			return nil
+		}
+	}
+	return errInvalidSignature
+}
+
+func verifyWithKey(key crypto.PublicKey, payload, signature []byte) bool {
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, payload, signature)
+	case *ecdsa.PublicKey:
+		// The digest follows the curve size, as in ES256/ES384/ES512.
+		var digest []byte
+		switch k.Curve {
+		case elliptic.P384():
+			sum := sha512.Sum384(payload)
+			digest = sum[:]
+		case elliptic.P521():
+			sum := sha512.Sum512(payload)
+			digest = sum[:]
+		default:
+			sum := sha256.Sum256(payload)
+			digest = sum[:]
+		}
+		return ecdsa.VerifyASN1(k, digest, signature)
+	default:
+		return false
+	}
+}
+
+// loadTrustedKeys reads the public keys referenced by the spec. Values that
+// do not hold an ed25519 or ECDSA PKIX public key are skipped.
+func (r *ConfigMapSyncReconciler) loadTrustedKeys(ctx context.Context, namespace string, ref appsv2.KeyReference) ([]crypto.PublicKey, error) {
+	key := types.NamespacedName{Name: ref.Name, Namespace: namespace}
+	kind := ref.Kind
+	if kind == "" {
+		kind = "Secret"
+	}
+
+	var values [][]byte
+	if kind == "ConfigMap" {
+		// Only destination ConfigMaps are cached, so the keys are read from
+		// the API server
+		reader := client.Reader(r.Client)
+		if r.APIReader != nil {
+			reader = r.APIReader
+		}
+		configMap := &corev1.ConfigMap{}
+		if err := reader.Get(ctx, key, configMap); err != nil {
+			return nil, fmt.Errorf("failed to get trusted keys ConfigMap %s: %w", key, err)
+		}
+		for _, value := range configMap.Data {
+			values = append(values, []byte(value))
+		}
+		for _, value := range configMap.BinaryData {
+			values = append(values, value)
+		}
+	} else {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, key, secret); err != nil {
+			return nil, fmt.Errorf("failed to get trusted keys Secret %s: %w", key, err)
+		}
+		for _, value := range secret.Data {
+			values = append(values, value)
+		}
+	}
+
+	var trustedKeys []crypto.PublicKey
+	for _, value := range values {
+		for rest := value; ; {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				continue
+			}
+			switch publicKey.(type) {
+			case ed25519.PublicKey, *ecdsa.PublicKey:
+				trustedKeys = append(trustedKeys, publicKey)
+			}
+		}
+	}
+	if len(trustedKeys) == 0 {
+		return nil, fmt.Errorf("%w in %s %s", errNoTrustedKeys, kind, key)
+	}
+	return trustedKeys, nil
+}
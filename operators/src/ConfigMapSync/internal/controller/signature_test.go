@@ -0,0 +1,114 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+var _ = Describe("Source signature verification", func() {
+	newSource := func() *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}},
+			Data:       map[string]string{"app.name": "MyApp", "port": "8080"},
+			BinaryData: map[string][]byte{"logo.png": {0x89, 0x50, 0x4e, 0x47}},
+		}
+	}
+
+	It("should accept content signed with a trusted ed25519 key", func() {
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		source := newSource()
+		signature := ed25519.Sign(privateKey, canonicalSourceContent(source))
+		source.Annotations[SignatureAnnotation] = base64.StdEncoding.EncodeToString(signature)
+
+		Expect(verifySourceSignature(source, []crypto.PublicKey{publicKey})).To(Succeed())
+	})
+
+	It("should accept content signed with a trusted ECDSA key", func() {
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		source := newSource()
+		digest := sha256.Sum256(canonicalSourceContent(source))
+		signature, err := ecdsa.SignASN1(rand.Reader, privateKey, digest[:])
+		Expect(err).NotTo(HaveOccurred())
+		source.Annotations[SignatureAnnotation] = base64.StdEncoding.EncodeToString(signature)
+
+		Expect(verifySourceSignature(source, []crypto.PublicKey{&privateKey.PublicKey})).To(Succeed())
+	})
+
+	It("should reject unsigned and tampered content", func() {
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		source := newSource()
+		Expect(verifySourceSignature(source, []crypto.PublicKey{publicKey})).To(MatchError(errUnsigned))
+
+		signature := ed25519.Sign(privateKey, canonicalSourceContent(source))
+		source.Annotations[SignatureAnnotation] = base64.StdEncoding.EncodeToString(signature)
+		source.BinaryData["logo.png"] = []byte{0x00}
+		Expect(verifySourceSignature(source, []crypto.PublicKey{publicKey})).To(MatchError(errInvalidSignature))
+	})
+
+	It("should read an unlabelled trusted keys ConfigMap from the API server", func() {
+		publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		der, err := x509.MarshalPKIXPublicKey(publicKey)
+		Expect(err).NotTo(HaveOccurred())
+		keys := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "trusted-keys", Namespace: "team-a"},
+			Data:       map[string]string{"release.pub": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))},
+		}
+
+		// The manager cache only holds labelled destinations, so the client
+		// does not see the keys
+		reconciler := &ConfigMapSyncReconciler{
+			Client:    fake.NewClientBuilder().Build(),
+			APIReader: fake.NewClientBuilder().WithObjects(keys).Build(),
+		}
+		trustedKeys, err := reconciler.loadTrustedKeys(context.Background(), "team-a",
+			appsv2.KeyReference{Kind: "ConfigMap", Name: "trusted-keys"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(trustedKeys).To(Equal([]crypto.PublicKey{publicKey}))
+	})
+
+	It("should encode maps with shifted separators differently", func() {
+		a := &corev1.ConfigMap{Data: map[string]string{"a": "b c:d"}}
+		b := &corev1.ConfigMap{Data: map[string]string{"a": "b", "c": "d"}}
+		Expect(canonicalSourceContent(a)).NotTo(Equal(canonicalSourceContent(b)))
+	})
+})
@@ -0,0 +1,264 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// SOPSMetadataAnnotation carries the "sops" stanza of the encrypted
+	// ConfigMap manifest, as YAML or JSON. The API server drops unknown
+	// top-level fields, so the stanza has to travel in an annotation.
+	SOPSMetadataAnnotation = "configmapsync.apps.kapendra.com/sops"
+)
+
+// sopsValue matches a single SOPS-encrypted scalar.
+var sopsValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:([^,]*),iv:([^,]+),tag:([^,]+),type:([a-z]+)\]$`)
+
+// sopsMetadata is the subset of the SOPS stanza needed for age decryption
+// and for verifying the MAC.
+type sopsMetadata struct {
+	Age []struct {
+		Recipient string `json:"recipient"`
+		Enc       string `json:"enc"`
+	} `json:"age"`
+	// MAC is the encrypted digest of the values, authenticated with
+	// LastModified.
+	MAC              string `json:"mac"`
+	LastModified     string `json:"lastmodified"`
+	MACOnlyEncrypted bool   `json:"mac_only_encrypted"`
+}
+
+// decryptionError lists the keys that could not be decrypted and why. The
+// reasons are fixed strings so that no plaintext or key material can leak
+// into status or logs.
+type decryptionError struct {
+	reasons map[string]string
+}
+
+func (e *decryptionError) Error() string {
+	keys := make([]string, 0, len(e.reasons))
+	for key := range e.reasons {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s (%s)", key, e.reasons[key]))
+	}
+	return "failed to decrypt source keys: " + strings.Join(parts, ", ")
+}
+
+// isSOPSEncrypted reports whether any Data value of the source is a SOPS
+// ciphertext.
+func isSOPSEncrypted(source *corev1.ConfigMap) bool {
+	for _, value := range source.Data {
+		if sopsValue.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// decryptSOPSData returns a copy of the source Data with every SOPS-encrypted
+// value replaced by its plaintext. Values that are not encrypted are copied
+// as they are. The data key is recovered from the age recipients in the
+// metadata annotation using any of the given identities, and the decrypted
+// values must match the MAC, so that encrypted values cannot be dropped,
+// swapped for older ones or taken from another file.
+func decryptSOPSData(source *corev1.ConfigMap, identities []age.Identity) (map[string]string, error) {
+	var encryptedKeys []string
+	for key, value := range source.Data {
+		if sopsValue.MatchString(value) {
+			encryptedKeys = append(encryptedKeys, key)
+		}
+	}
+
+	failAll := func(reason string) error {
+		reasons := make(map[string]string, len(encryptedKeys))
+		for _, key := range encryptedKeys {
+			reasons[key] = reason
+		}
+		return &decryptionError{reasons: reasons}
+	}
+
+	raw, ok := source.Annotations[SOPSMetadataAnnotation]
+	if !ok {
+		return nil, failAll("missing sops metadata annotation")
+	}
+	metadata := sopsMetadata{}
+	if err := yaml.Unmarshal([]byte(raw), &metadata); err != nil {
+		return nil, failAll("malformed sops metadata")
+	}
+	if len(metadata.Age) == 0 {
+		return nil, failAll("no age recipients in sops metadata")
+	}
+
+	dataKey, err := recoverSOPSDataKey(metadata, identities)
+	if err != nil {
+		return nil, failAll("no matching age identity")
+	}
+
+	reasons := make(map[string]string)
+	decrypted := make(map[string]string, len(source.Data))
+	for key, value := range source.Data {
+		if !sopsValue.MatchString(value) {
+			decrypted[key] = value
+			continue
+		}
+		plaintext, reason := decryptSOPSValue(dataKey, value, "data:"+key+":")
+		if reason != "" {
+			reasons[key] = reason
+			continue
+		}
+		decrypted[key] = plaintext
+	}
+	if len(reasons) > 0 {
+		return nil, &decryptionError{reasons: reasons}
+	}
+	if reason := verifySOPSMAC(metadata, dataKey, encryptedKeys, decrypted); reason != "" {
+		return nil, failAll(reason)
+	}
+	return decrypted, nil
+}
+
+// verifySOPSMAC checks the MAC the way sops decrypt does: it is the SHA-512
+// of the plaintext values in document order, encrypted with the data key and
+// the last-modified time. Only the data of the manifest reaches the
+// ConfigMap, so the MAC has to cover the encrypted values alone
+// (mac_only_encrypted), and their keys are taken in sorted order, the order
+// kubectl writes them in. It returns the reason the MAC does not match, or
+// "".
+func verifySOPSMAC(metadata sopsMetadata, dataKey []byte, encryptedKeys []string, decrypted map[string]string) string {
+	if metadata.MAC == "" {
+		return "missing sops mac"
+	}
+	if !metadata.MACOnlyEncrypted {
+		return "sops mac covers unencrypted values"
+	}
+	lastModified, err := time.Parse(time.RFC3339, metadata.LastModified)
+	if err != nil {
+		return "malformed sops lastmodified"
+	}
+	if !sopsValue.MatchString(metadata.MAC) {
+		return "malformed sops mac"
+	}
+	expected, reason := decryptSOPSValue(dataKey, metadata.MAC, lastModified.Format(time.RFC3339))
+	if reason != "" {
+		return "sops mac " + reason
+	}
+
+	sort.Strings(encryptedKeys)
+	hash := sha512.New()
+	for _, key := range encryptedKeys {
+		hash.Write([]byte(decrypted[key]))
+	}
+	if fmt.Sprintf("%X", hash.Sum(nil)) != expected {
+		return "sops mac mismatch"
+	}
+	return ""
+}
+
+func recoverSOPSDataKey(metadata sopsMetadata, identities []age.Identity) ([]byte, error) {
+	for _, recipient := range metadata.Age {
+		reader, err := age.Decrypt(armor.NewReader(strings.NewReader(recipient.Enc)), identities...)
+		if err != nil {
+			continue
+		}
+		dataKey, err := io.ReadAll(reader)
+		if err != nil || len(dataKey) != 32 {
+			continue
+		}
+		return dataKey, nil
+	}
+	return nil, errors.New("no age identity matches the sops recipients")
+}
+
+// decryptSOPSValue decrypts one value. SOPS authenticates each value with
+// additionalData: its tree path, which for a ConfigMap manifest is
+// "data:<key>:", or the last-modified time for the MAC.
+func decryptSOPSValue(dataKey []byte, value, additionalData string) (string, string) {
+	match := sopsValue.FindStringSubmatch(value)
+	ciphertext, err := base64.StdEncoding.DecodeString(match[1])
+	if err != nil {
+		return "", "malformed ciphertext"
+	}
+	iv, err := base64.StdEncoding.DecodeString(match[2])
+	if err != nil || len(iv) == 0 {
+		return "", "malformed iv"
+	}
+	tag, err := base64.StdEncoding.DecodeString(match[3])
+	if err != nil {
+		return "", "malformed tag"
+	}
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return "", "invalid data key"
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return "", "invalid iv"
+	}
+	plaintext, err := gcm.Open(nil, iv, append(ciphertext, tag...), []byte(additionalData))
+	if err != nil {
+		return "", "authentication failed"
+	}
+	return string(plaintext), ""
+}
+
+// loadAgeIdentities reads age identities from every value of the referenced
+// Secret.
+func (r *ConfigMapSyncReconciler) loadAgeIdentities(ctx context.Context, namespace, name string) ([]age.Identity, error) {
+	key := types.NamespacedName{Name: name, Namespace: namespace}
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, key, secret); err != nil {
+		return nil, fmt.Errorf("failed to get decryption Secret %s: %w", key, err)
+	}
+
+	var identities []age.Identity
+	for _, value := range secret.Data {
+		parsed, err := age.ParseIdentities(strings.NewReader(string(value)))
+		if err != nil {
+			continue
+		}
+		identities = append(identities, parsed...)
+	}
+	if len(identities) == 0 {
+		return nil, fmt.Errorf("no age identities found in Secret %s", key)
+	}
+	return identities, nil
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// sopsEncrypt produces a value in the format written by sops for the given
+// ConfigMap key.
+func sopsEncrypt(dataKey []byte, key, plaintext string) string {
+	return sopsSeal(dataKey, plaintext, "data:"+key+":")
+}
+
+// sopsSeal encrypts plaintext the way sops does, authenticated with
+// additionalData.
+func sopsSeal(dataKey []byte, plaintext, additionalData string) string {
+	block, err := aes.NewCipher(dataKey)
+	Expect(err).NotTo(HaveOccurred())
+	iv := make([]byte, 32)
+	_, err = rand.Read(iv)
+	Expect(err).NotTo(HaveOccurred())
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	Expect(err).NotTo(HaveOccurred())
+
+	sealed := gcm.Seal(nil, iv, []byte(plaintext), []byte(additionalData))
+	ciphertext, tag := sealed[:len(sealed)-16], sealed[len(sealed)-16:]
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:str]",
+		base64.StdEncoding.EncodeToString(ciphertext),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(tag))
+}
+
+// sopsMetadataFor wraps the data key for the recipient the way sops does,
+// with a MAC over the encrypted values' plaintexts in key order.
+func sopsMetadataFor(recipient *age.X25519Recipient, dataKey []byte, plaintexts ...string) string {
+	var armored bytes.Buffer
+	armorWriter := armor.NewWriter(&armored)
+	writer, err := age.Encrypt(armorWriter, recipient)
+	Expect(err).NotTo(HaveOccurred())
+	_, err = writer.Write(dataKey)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(writer.Close()).To(Succeed())
+	Expect(armorWriter.Close()).To(Succeed())
+
+	hash := sha512.New()
+	for _, plaintext := range plaintexts {
+		hash.Write([]byte(plaintext))
+	}
+	lastModified := "2026-10-18T12:00:00Z"
+	metadata, err := json.Marshal(map[string]any{
+		"age":                []map[string]string{{"recipient": recipient.String(), "enc": armored.String()}},
+		"lastmodified":       lastModified,
+		"mac":                sopsSeal(dataKey, fmt.Sprintf("%X", hash.Sum(nil)), lastModified),
+		"mac_only_encrypted": true,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	return string(metadata)
+}
+
+var _ = Describe("SOPS decryption", func() {
+	var (
+		identity *age.X25519Identity
+		dataKey  []byte
+		source   *corev1.ConfigMap
+	)
+
+	BeforeEach(func() {
+		var err error
+		identity, err = age.GenerateX25519Identity()
+		Expect(err).NotTo(HaveOccurred())
+		dataKey = make([]byte, 32)
+		_, err = rand.Read(dataKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		source = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					SOPSMetadataAnnotation: sopsMetadataFor(identity.Recipient(), dataKey, "hunter2"),
+				},
+			},
+			Data: map[string]string{
+				"database.password": sopsEncrypt(dataKey, "database.password", "hunter2"),
+				"app.name":          "MyApp",
+			},
+		}
+	})
+
+	It("should decrypt encrypted values and keep plaintext values", func() {
+		Expect(isSOPSEncrypted(source)).To(BeTrue())
+
+		decrypted, err := decryptSOPSData(source, []age.Identity{identity})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decrypted).To(Equal(map[string]string{
+			"database.password": "hunter2",
+			"app.name":          "MyApp",
+		}))
+	})
+
+	It("should report failures per key without leaking values", func() {
+		// A value moved to another key no longer authenticates.
+		source.Data["api.token"] = sopsEncrypt(dataKey, "other.key", "s3cr3t")
+
+		_, err := decryptSOPSData(source, []age.Identity{identity})
+		Expect(err).To(MatchError("failed to decrypt source keys: api.token (authentication failed)"))
+
+		other, err := age.GenerateX25519Identity()
+		Expect(err).NotTo(HaveOccurred())
+		_, err = decryptSOPSData(source, []age.Identity{other})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("database.password (no matching age identity)"))
+		Expect(err.Error()).NotTo(ContainSubstring("hunter2"))
+	})
+
+	It("should reject values that do not match the MAC", func() {
+		// An older value that still authenticates on its own
+		source.Data["database.password"] = sopsEncrypt(dataKey, "database.password", "hunter1")
+		_, err := decryptSOPSData(source, []age.Identity{identity})
+		Expect(err).To(MatchError("failed to decrypt source keys: database.password (sops mac mismatch)"))
+
+		// An encrypted value added from another file with the same data key
+		source.Data["database.password"] = sopsEncrypt(dataKey, "database.password", "hunter2")
+		source.Data["api.token"] = sopsEncrypt(dataKey, "api.token", "s3cr3t")
+		_, err = decryptSOPSData(source, []age.Identity{identity})
+		Expect(err).To(MatchError(ContainSubstring("api.token (sops mac mismatch)")))
+
+		source.Annotations[SOPSMetadataAnnotation] = sopsMetadataFor(identity.Recipient(), dataKey, "s3cr3t", "hunter2")
+		Expect(decryptSOPSData(source, []age.Identity{identity})).To(HaveKeyWithValue("api.token", "s3cr3t"))
+	})
+
+	It("should require a MAC over the encrypted values only", func() {
+		metadata := map[string]any{}
+		Expect(json.Unmarshal([]byte(source.Annotations[SOPSMetadataAnnotation]), &metadata)).To(Succeed())
+		metadata["mac_only_encrypted"] = false
+		withUnencrypted, err := json.Marshal(metadata)
+		Expect(err).NotTo(HaveOccurred())
+		source.Annotations[SOPSMetadataAnnotation] = string(withUnencrypted)
+		_, err = decryptSOPSData(source, []age.Identity{identity})
+		Expect(err).To(MatchError(ContainSubstring("sops mac covers unencrypted values")))
+
+		delete(metadata, "mac")
+		withoutMAC, err := json.Marshal(metadata)
+		Expect(err).NotTo(HaveOccurred())
+		source.Annotations[SOPSMetadataAnnotation] = string(withoutMAC)
+		_, err = decryptSOPSData(source, []age.Identity{identity})
+		Expect(err).To(MatchError(ContainSubstring("missing sops mac")))
+	})
+})
@@ -0,0 +1,164 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// maxChangeSummaryKeys bounds each key list in the change summary so a sync
+// of a very large ConfigMap cannot bloat the status.
+const maxChangeSummaryKeys = 50
+
+// summarizeChange lists the keys added, removed and changed between the
+// previous and the new destination data.
+func summarizeChange(previous, current map[string]string) *appsv2.SyncChangeSummary {
+	summary := &appsv2.SyncChangeSummary{}
+	for key, value := range current {
+		previousValue, existed := previous[key]
+		switch {
+		case !existed:
+			summary.Added = append(summary.Added, key)
+		case previousValue != value:
+			summary.Changed = append(summary.Changed, key)
+		}
+	}
+	for key := range previous {
+		if _, exists := current[key]; !exists {
+			summary.Removed = append(summary.Removed, key)
+		}
+	}
+
+	for _, keys := range []*[]string{&summary.Added, &summary.Removed, &summary.Changed} {
+		sort.Strings(*keys)
+		if len(*keys) > maxChangeSummaryKeys {
+			*keys = (*keys)[:maxChangeSummaryKeys]
+			summary.Truncated = true
+		}
+	}
+	return summary
+}
+
+// recordSourceState copies what was synced from the source into the status.
+func recordSourceState(configMapSync *appsv2.ConfigMapSync, source *corev1.ConfigMap, sourceHash string, syncData map[string]string) {
+	configMapSync.Status.Source.ResourceVersion = source.ResourceVersion
+	configMapSync.Status.Source.SyncedHash = sourceHash
+	configMapSync.Status.KeyCount = len(syncData)
+	configMapSync.Status.ByteSize = configMapSize(&corev1.ConfigMap{Data: syncData})
+}
+
+// markReady reports a converged sync in kstatus terms: Ready is True and
+// the abnormal-true conditions are cleared.
+func (r *ConfigMapSyncReconciler) markReady(configMapSync *appsv2.ConfigMapSync, reason, message string) {
+	r.setCondition(configMapSync, TypeReady, metav1.ConditionTrue, reason, message)
+	meta.RemoveStatusCondition(&configMapSync.Status.Conditions, TypeReconciling)
+	meta.RemoveStatusCondition(&configMapSync.Status.Conditions, TypeStalled)
+}
+
+// markReconciling reports a sync that is still working towards the desired
+// state, for example while retrying after a transient API error.
+func (r *ConfigMapSyncReconciler) markReconciling(configMapSync *appsv2.ConfigMapSync, reason, message string) {
+	r.setCondition(configMapSync, TypeReady, metav1.ConditionFalse, reason, message)
+	r.setCondition(configMapSync, TypeReconciling, metav1.ConditionTrue, reason, message)
+	meta.RemoveStatusCondition(&configMapSync.Status.Conditions, TypeStalled)
+}
+
+// markStalled reports a sync that cannot make progress until its spec, the
+// source or the cluster configuration changes.
+func (r *ConfigMapSyncReconciler) markStalled(configMapSync *appsv2.ConfigMapSync, reason, message string) {
+	r.setCondition(configMapSync, TypeReady, metav1.ConditionFalse, reason, message)
+	r.setCondition(configMapSync, TypeStalled, metav1.ConditionTrue, reason, message)
+	meta.RemoveStatusCondition(&configMapSync.Status.Conditions, TypeReconciling)
+}
+
+// holdChange reports a change that is not written yet: status.pending
+// describes it and Synced and Reconciling carry its reason. The sync is
+// requeued for when the change is due; without a due time it waits for an
+// event instead.
+func (r *ConfigMapSyncReconciler) holdChange(ctx context.Context, configMapSync *appsv2.ConfigMapSync, originalStatus *appsv2.ConfigMapSyncStatus, change *appsv2.PendingChange, now time.Time) (ctrl.Result, error) {
+	configMapSync.Status.Pending = change
+	configMapSync.Status.Message = change.Message
+	r.setCondition(configMapSync, TypeSynced, metav1.ConditionFalse, change.Reason, change.Message)
+	r.markReconciling(configMapSync, change.Reason, change.Message)
+	if err := r.updateStatus(ctx, configMapSync, originalStatus); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update ConfigMapSync status")
+		return ctrl.Result{}, err
+	}
+	if change.NotBefore == nil {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{RequeueAfter: change.NotBefore.Sub(now)}, nil
+}
+
+// updateStatus writes the ConfigMapSync status inside a traced span, stamped
+// with the generation it was computed from. Nothing is written when the
+// status matches original apart from LastSyncTime, so a steady-state
+// reconcile makes no API writes.
+//
+// The status is merge-patched onto a fresh read of the object with an
+// optimistic lock, and re-read and patched again when the object changed in
+// between, so a concurrent spec or metadata update does not drop it. The
+// reads bypass the cache, which may not have seen the change yet.
+func (r *ConfigMapSyncReconciler) updateStatus(ctx context.Context, configMapSync *appsv2.ConfigMapSync, original *appsv2.ConfigMapSyncStatus) error {
+	configMapSync.Status.ObservedGeneration = configMapSync.Generation
+	if original != nil && statusUnchanged(original, &configMapSync.Status) {
+		configMapSync.Status.LastSyncTime = original.LastSyncTime
+		return nil
+	}
+	key := types.NamespacedName{Namespace: configMapSync.Namespace, Name: configMapSync.Name}
+	return r.traceCall(ctx, "ConfigMapSync.UpdateStatus", key, func(ctx context.Context) error {
+		reader := client.Reader(r.Client)
+		if r.APIReader != nil {
+			reader = r.APIReader
+		}
+		return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			latest := &appsv2.ConfigMapSync{}
+			if err := reader.Get(ctx, key, latest); err != nil {
+				return err
+			}
+			patch := client.MergeFromWithOptions(latest.DeepCopy(), client.MergeFromWithOptimisticLock{})
+			latest.Status = *configMapSync.Status.DeepCopy()
+			if err := r.Status().Patch(ctx, latest, patch); err != nil {
+				return err
+			}
+			configMapSync.ResourceVersion = latest.ResourceVersion
+			return nil
+		})
+	})
+}
+
+// statusUnchanged reports whether two statuses differ in nothing but the
+// time of the sync attempt.
+func statusUnchanged(original, current *appsv2.ConfigMapSyncStatus) bool {
+	original, current = original.DeepCopy(), current.DeepCopy()
+	original.LastSyncTime, current.LastSyncTime = nil, nil
+	return equality.Semantic.DeepEqual(original, current)
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+var _ = Describe("Sync status", func() {
+	It("should summarise added, removed and changed keys in order", func() {
+		summary := summarizeChange(
+			map[string]string{"kept": "1", "changed": "old", "removed-b": "x", "removed-a": "y"},
+			map[string]string{"kept": "1", "changed": "new", "added": "z"},
+		)
+		Expect(summary).To(Equal(&appsv2.SyncChangeSummary{
+			Added:   []string{"added"},
+			Removed: []string{"removed-a", "removed-b"},
+			Changed: []string{"changed"},
+		}))
+	})
+
+	It("should truncate long key lists", func() {
+		current := map[string]string{}
+		for i := range maxChangeSummaryKeys + 5 {
+			current[fmt.Sprintf("key-%03d", i)] = "v"
+		}
+		summary := summarizeChange(nil, current)
+		Expect(summary.Added).To(HaveLen(maxChangeSummaryKeys))
+		Expect(summary.Added[0]).To(Equal("key-000"))
+		Expect(summary.Truncated).To(BeTrue())
+	})
+
+	It("should record the synced source version, hash and size", func() {
+		configMapSync := &appsv2.ConfigMapSync{}
+		source := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{ResourceVersion: "10"}}
+
+		recordSourceState(configMapSync, source, "hash", map[string]string{"key": "value"})
+
+		Expect(configMapSync.Status.Source.SyncedHash).To(Equal("hash"))
+		Expect(configMapSync.Status.Source.ResourceVersion).To(Equal("10"))
+		Expect(configMapSync.Status.KeyCount).To(Equal(1))
+		Expect(configMapSync.Status.ByteSize).To(Equal(int64(8)))
+	})
+})
+
+var _ = Describe("kstatus conditions", func() {
+	ctx := context.Background()
+	syncKey := types.NamespacedName{Name: "app-sync", Namespace: "team-a"}
+
+	var reconciler *ConfigMapSyncReconciler
+
+	BeforeEach(func() {
+		configMapSync := newTestSync(nil)
+		configMapSync.Generation = 2
+		reconciler = newFakeReconciler(interceptor.Funcs{}, configMapSync)
+		// Each spec creates the source when it needs one
+		Expect(reconciler.Delete(ctx, newTestSource(nil))).To(Succeed())
+	})
+
+	reconcile := func() *appsv2.ConfigMapSync {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+		Expect(err).NotTo(HaveOccurred())
+		configMapSync := &appsv2.ConfigMapSync{}
+		Expect(reconciler.Get(ctx, syncKey, configMapSync)).To(Succeed())
+		return configMapSync
+	}
+
+	It("should report a missing source as Stalled and recover to Ready", func() {
+		configMapSync := reconcile()
+		Expect(meta.IsStatusConditionTrue(configMapSync.Status.Conditions, TypeStalled)).To(BeTrue())
+		Expect(meta.IsStatusConditionFalse(configMapSync.Status.Conditions, TypeReady)).To(BeTrue())
+		Expect(meta.FindStatusCondition(configMapSync.Status.Conditions, TypeStalled).Reason).To(Equal("SourceNotFound"))
+
+		Expect(reconciler.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "team-a"},
+			Data:       map[string]string{"key": "value"},
+		})).To(Succeed())
+
+		configMapSync = reconcile()
+		Expect(meta.IsStatusConditionTrue(configMapSync.Status.Conditions, TypeReady)).To(BeTrue())
+		Expect(meta.FindStatusCondition(configMapSync.Status.Conditions, TypeStalled)).To(BeNil())
+		Expect(meta.FindStatusCondition(configMapSync.Status.Conditions, TypeReconciling)).To(BeNil())
+		Expect(configMapSync.Status.ObservedGeneration).To(Equal(int64(2)))
+		for _, condition := range configMapSync.Status.Conditions {
+			Expect(condition.ObservedGeneration).To(Equal(int64(2)), condition.Type)
+		}
+	})
+
+	It("should keep transition times while the status does not change", func() {
+		Expect(reconciler.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "team-a"},
+			Data:       map[string]string{"key": "value"},
+		})).To(Succeed())
+		configMapSync := reconcile()
+
+		// Backdate the conditions so a rewritten timestamp would be visible
+		transitioned := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+		for i := range configMapSync.Status.Conditions {
+			configMapSync.Status.Conditions[i].LastTransitionTime = transitioned
+		}
+		Expect(reconciler.Status().Update(ctx, configMapSync)).To(Succeed())
+
+		configMapSync = reconcile()
+		ready := meta.FindStatusCondition(configMapSync.Status.Conditions, TypeReady)
+		Expect(ready.Status).To(Equal(metav1.ConditionTrue))
+		Expect(ready.LastTransitionTime.Time).To(BeTemporally("==", transitioned.Time))
+	})
+
+	It("should make no writes once the sync reaches a steady state", func() {
+		Expect(reconciler.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "team-a"},
+			Data:       map[string]string{"key": "value"},
+		})).To(Succeed())
+		synced := reconcile()
+
+		writes := 0
+		reconciler.Client = countWrites(reconciler.Client.(client.WithWatch), &writes)
+		steady := reconcile()
+		Expect(writes).To(BeZero())
+		Expect(steady.ResourceVersion).To(Equal(synced.ResourceVersion))
+		Expect(steady.Status.LastSyncTime).To(Equal(synced.Status.LastSyncTime))
+	})
+
+	It("should converge the status while the object is updated concurrently", func() {
+		Expect(reconciler.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "team-a"},
+			Data:       map[string]string{"key": "value"},
+		})).To(Succeed())
+
+		// Bump the object before each of the first status patches so that
+		// every one of them is sent against a stale resourceVersion.
+		concurrentUpdates := 0
+		reconciler.Client = interceptor.NewClient(reconciler.Client.(client.WithWatch), interceptor.Funcs{
+			SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				if concurrentUpdates < 3 {
+					concurrentUpdates++
+					latest := &appsv2.ConfigMapSync{}
+					Expect(c.Get(ctx, syncKey, latest)).To(Succeed())
+					latest.Annotations = map[string]string{"example.com/touched": fmt.Sprint(concurrentUpdates)}
+					Expect(c.Update(ctx, latest)).To(Succeed())
+				}
+				return c.SubResource(subResourceName).Patch(ctx, obj, patch, opts...)
+			},
+		})
+
+		configMapSync := reconcile()
+		Expect(concurrentUpdates).To(Equal(3))
+		Expect(configMapSync.Annotations).To(HaveKeyWithValue("example.com/touched", "3"))
+		Expect(meta.IsStatusConditionTrue(configMapSync.Status.Conditions, TypeReady)).To(BeTrue())
+		Expect(configMapSync.Status.ObservedGeneration).To(Equal(int64(2)))
+		Expect(configMapSync.Status.Source.SyncedHash).NotTo(BeEmpty())
+	})
+
+	It("should re-read a conflicting object past a lagging cache", func() {
+		Expect(reconciler.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "team-a"},
+			Data:       map[string]string{"key": "value"},
+		})).To(Succeed())
+
+		// The object is updated, but the cache keeps serving the old version
+		apiServer := reconciler.Client.(client.WithWatch)
+		stale := &appsv2.ConfigMapSync{}
+		Expect(apiServer.Get(ctx, syncKey, stale)).To(Succeed())
+		latest := stale.DeepCopy()
+		latest.Annotations = map[string]string{"example.com/touched": "true"}
+		Expect(apiServer.Update(ctx, latest)).To(Succeed())
+		reconciler.Client = interceptor.NewClient(apiServer, interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if cached, ok := obj.(*appsv2.ConfigMapSync); ok {
+					stale.DeepCopyInto(cached)
+					return nil
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+		})
+		reconciler.APIReader = apiServer
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+		Expect(err).NotTo(HaveOccurred())
+		configMapSync := &appsv2.ConfigMapSync{}
+		Expect(apiServer.Get(ctx, syncKey, configMapSync)).To(Succeed())
+		Expect(configMapSync.Annotations).To(HaveKeyWithValue("example.com/touched", "true"))
+		Expect(meta.IsStatusConditionTrue(configMapSync.Status.Conditions, TypeReady)).To(BeTrue())
+	})
+
+	It("should report a failed destination write as Reconciling", func() {
+		Expect(reconciler.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "team-a"},
+			Data:       map[string]string{"key": "value"},
+		})).To(Succeed())
+		reconciler.Client = interceptApply(reconciler.Client.(client.WithWatch))
+
+		for attempt := 1; attempt <= 2; attempt++ {
+			result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+			Expect(err).To(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeZero(), "retry timing is left to the workqueue")
+
+			configMapSync := &appsv2.ConfigMapSync{}
+			Expect(reconciler.Get(ctx, syncKey, configMapSync)).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(configMapSync.Status.Conditions, TypeReconciling)).To(BeTrue())
+			Expect(meta.IsStatusConditionFalse(configMapSync.Status.Conditions, TypeReady)).To(BeTrue())
+			Expect(configMapSync.Status.RetryCount).To(Equal(attempt))
+		}
+	})
+})
@@ -20,6 +20,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -32,7 +33,7 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
-	appsv1 "operators/src/ConfigMapSync/api/v1"
+	appsv2 "operators/src/ConfigMapSync/api/v2"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -45,6 +46,9 @@ var (
 	testEnv   *envtest.Environment
 	cfg       *rest.Config
 	k8sClient client.Client
+
+	envTestOnce sync.Once
+	envTestErr  error
 )
 
 func TestControllers(t *testing.T) {
@@ -58,12 +62,41 @@ var _ = BeforeSuite(func() {
 
 	ctx, cancel = context.WithCancel(context.TODO())
 
-	var err error
-	err = appsv1.AddToScheme(scheme.Scheme)
+	err := appsv2.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
 	// +kubebuilder:scaffold:scheme
+})
+
+var _ = AfterSuite(func() {
+	cancel()
+	if testEnv == nil {
+		return
+	}
+	By("tearing down the test environment")
+	err := testEnv.Stop()
+	Expect(err).NotTo(HaveOccurred())
+})
+
+// useEnvTest makes the specs of the calling container run against a test
+// API server, started the first time a spec needs it. Most specs use the
+// fake client and never start it, so they run without the envtest
+// binaries; the envtest specs are skipped when those are not installed.
+func useEnvTest() {
+	BeforeEach(func() {
+		if os.Getenv("KUBEBUILDER_ASSETS") == "" && getFirstFoundEnvTestBinaryDir() == "" {
+			Skip("envtest binaries not found, run 'make setup-envtest' or 'make test'")
+		}
+		envTestOnce.Do(func() {
+			envTestErr = startEnvTest()
+		})
+		Expect(envTestErr).NotTo(HaveOccurred())
+	})
+}
 
+// startEnvTest starts the test API server with the CRDs installed and
+// connects k8sClient to it.
+func startEnvTest() error {
 	By("bootstrapping test environment")
 	testEnv = &envtest.Environment{
 		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "config", "crd", "bases")},
@@ -76,21 +109,13 @@ var _ = BeforeSuite(func() {
 	}
 
 	// cfg is defined in this file globally.
-	cfg, err = testEnv.Start()
-	Expect(err).NotTo(HaveOccurred())
-	Expect(cfg).NotTo(BeNil())
-
+	var err error
+	if cfg, err = testEnv.Start(); err != nil {
+		return err
+	}
 	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme.Scheme})
-	Expect(err).NotTo(HaveOccurred())
-	Expect(k8sClient).NotTo(BeNil())
-})
-
-var _ = AfterSuite(func() {
-	By("tearing down the test environment")
-	cancel()
-	err := testEnv.Stop()
-	Expect(err).NotTo(HaveOccurred())
-})
+	return err
+}
 
 // getFirstFoundEnvTestBinaryDir locates the first binary in the specified path.
 // ENVTEST-based tests depend on specific binaries, usually located in paths set by
@@ -0,0 +1,56 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// ReconcileRequestAnnotation requests a full resync whenever its value
+	// changes, in the manner of Flux's reconcile.fluxcd.io/requestedAt. The
+	// handled value is recorded in status.lastHandledReconcileAt.
+	ReconcileRequestAnnotation = "configmapsync.apps.kapendra.com/requested-at"
+
+	// TypeSuspended is present while spec.suspend stops the sync.
+	TypeSuspended = "Suspended"
+)
+
+// resyncRequested returns the value of the requested-at annotation and
+// whether it is one the controller has not handled yet.
+func resyncRequested(configMapSync *appsv2.ConfigMapSync) (string, bool) {
+	requestedAt := configMapSync.Annotations[ReconcileRequestAnnotation]
+	return requestedAt, requestedAt != "" && requestedAt != configMapSync.Status.LastHandledReconcileAt
+}
+
+// suspendSync reports a suspended ConfigMapSync and does nothing else. It
+// is not requeued; resuming changes the spec, which triggers a reconcile.
+func (r *ConfigMapSyncReconciler) suspendSync(ctx context.Context, configMapSync *appsv2.ConfigMapSync, originalStatus *appsv2.ConfigMapSyncStatus) (ctrl.Result, error) {
+	log.FromContext(ctx).Info("ConfigMapSync is suspended, skipping sync")
+	r.setCondition(configMapSync, TypeSuspended, metav1.ConditionTrue, "Suspended", "Sync is suspended, destinations keep their last synced content")
+	if err := r.updateStatus(ctx, configMapSync, originalStatus); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update ConfigMapSync status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
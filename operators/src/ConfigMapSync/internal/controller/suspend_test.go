@@ -0,0 +1,131 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+var _ = Describe("Suspend and resync requests", func() {
+	ctx := context.Background()
+	syncKey := types.NamespacedName{Name: "app-sync", Namespace: "team-a"}
+	destinationKey := types.NamespacedName{Name: "app-config", Namespace: "tenant-1"}
+
+	var (
+		reconciler *ConfigMapSyncReconciler
+		applies    int
+	)
+
+	BeforeEach(func() {
+		applies = 0
+		reconciler = newFakeReconciler(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				if patch.Type() == types.ApplyPatchType {
+					applies++
+				}
+				return emulateApply(ctx, c, obj, patch, opts...)
+			},
+		}, newTestSync(func(spec *appsv2.ConfigMapSyncSpec) {
+			spec.Suspend = true
+		}))
+	})
+
+	reconcile := func() *appsv2.ConfigMapSync {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+		Expect(err).NotTo(HaveOccurred())
+		configMapSync := &appsv2.ConfigMapSync{}
+		Expect(reconciler.Get(ctx, syncKey, configMapSync)).To(Succeed())
+		return configMapSync
+	}
+
+	update := func(mutate func(*appsv2.ConfigMapSync)) {
+		configMapSync := &appsv2.ConfigMapSync{}
+		Expect(reconciler.Get(ctx, syncKey, configMapSync)).To(Succeed())
+		mutate(configMapSync)
+		Expect(reconciler.Update(ctx, configMapSync)).To(Succeed())
+	}
+
+	It("should only report Suspended until the sync is resumed", func() {
+		configMapSync := reconcile()
+		Expect(meta.IsStatusConditionTrue(configMapSync.Status.Conditions, TypeSuspended)).To(BeTrue())
+		Expect(applies).To(BeZero())
+		err := reconciler.Get(ctx, destinationKey, &corev1.ConfigMap{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		update(func(configMapSync *appsv2.ConfigMapSync) { configMapSync.Spec.Suspend = false })
+		configMapSync = reconcile()
+		Expect(meta.FindStatusCondition(configMapSync.Status.Conditions, TypeSuspended)).To(BeNil())
+		Expect(meta.IsStatusConditionTrue(configMapSync.Status.Conditions, TypeReady)).To(BeTrue())
+		Expect(reconciler.Get(ctx, destinationKey, &corev1.ConfigMap{})).To(Succeed())
+	})
+
+	It("should still clean up the destinations of a suspended sync on deletion", func() {
+		update(func(configMapSync *appsv2.ConfigMapSync) { configMapSync.Spec.Suspend = false })
+		reconcile()
+		update(func(configMapSync *appsv2.ConfigMapSync) { configMapSync.Spec.Suspend = true })
+		Expect(reconciler.Delete(ctx, &appsv2.ConfigMapSync{ObjectMeta: metav1.ObjectMeta{Name: syncKey.Name, Namespace: syncKey.Namespace}})).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+		Expect(err).NotTo(HaveOccurred())
+		err = reconciler.Get(ctx, destinationKey, &corev1.ConfigMap{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should rewrite up-to-date destinations once per requested-at value", func() {
+		update(func(configMapSync *appsv2.ConfigMapSync) { configMapSync.Spec.Suspend = false })
+		reconcile()
+		Expect(applies).To(Equal(1))
+
+		// Nothing changed, so nothing is written
+		reconcile()
+		Expect(applies).To(Equal(1))
+
+		update(func(configMapSync *appsv2.ConfigMapSync) {
+			configMapSync.Annotations = map[string]string{ReconcileRequestAnnotation: "2026-10-18T12:00:00Z"}
+		})
+		configMapSync := reconcile()
+		Expect(applies).To(Equal(2))
+		Expect(configMapSync.Status.LastHandledReconcileAt).To(Equal("2026-10-18T12:00:00Z"))
+
+		// The handled value does not force another resync
+		reconcile()
+		Expect(applies).To(Equal(2))
+	})
+
+	It("should not handle resync requests while suspended", func() {
+		update(func(configMapSync *appsv2.ConfigMapSync) {
+			configMapSync.Annotations = map[string]string{ReconcileRequestAnnotation: "now"}
+		})
+		configMapSync := reconcile()
+		Expect(applies).To(BeZero())
+		Expect(configMapSync.Status.LastHandledReconcileAt).To(BeEmpty())
+	})
+})
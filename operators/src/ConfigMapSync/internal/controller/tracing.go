@@ -0,0 +1,110 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// TracerName identifies the spans produced by this controller.
+const TracerName = "operators/src/ConfigMapSync/internal/controller"
+
+// Span attributes describing the sync being reconciled.
+const (
+	attrSyncName         = attribute.Key("configmapsync.name")
+	attrSyncNamespace    = attribute.Key("configmapsync.namespace")
+	attrSyncSource       = attribute.Key("configmapsync.source")
+	attrSyncDestinations = attribute.Key("configmapsync.destinations")
+	attrObjectName       = attribute.Key("k8s.object.name")
+	attrObjectNamespace  = attribute.Key("k8s.object.namespace")
+)
+
+// tracer returns the configured tracer, falling back to the global provider,
+// which is a no-op unless tracing was enabled at startup.
+func (r *ConfigMapSyncReconciler) tracer() trace.Tracer {
+	if r.Tracer != nil {
+		return r.Tracer
+	}
+	return otel.Tracer(TracerName)
+}
+
+// startReconcileSpan opens the root span of a reconcile and returns a context
+// whose logger carries the trace and span IDs, so log lines can be joined
+// with the trace.
+func (r *ConfigMapSyncReconciler) startReconcileSpan(ctx context.Context, key types.NamespacedName) (context.Context, trace.Span) {
+	ctx, span := r.tracer().Start(ctx, "ConfigMapSync.Reconcile", trace.WithAttributes(
+		attrSyncName.String(key.Name),
+		attrSyncNamespace.String(key.Namespace),
+	))
+	if spanContext := span.SpanContext(); spanContext.IsValid() {
+		logger := log.FromContext(ctx).WithValues(
+			"traceID", spanContext.TraceID().String(),
+			"spanID", spanContext.SpanID().String(),
+		)
+		ctx = log.IntoContext(ctx, logger)
+	}
+	return ctx, span
+}
+
+// annotateSyncSpan adds the source and destinations of the sync to the span.
+func annotateSyncSpan(span trace.Span, configMapSync *appsv2.ConfigMapSync) {
+	var destinations []string
+	for _, key := range destinationKeys(configMapSync) {
+		destinations = append(destinations, key.String())
+	}
+	span.SetAttributes(
+		attrSyncSource.String(sourceKey(configMapSync).String()),
+		attrSyncDestinations.StringSlice(destinations),
+	)
+}
+
+// endSpan marks the span failed when err is set and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traceCall runs one API call inside a child span. A NotFound result is an
+// expected outcome for lookups, so it is recorded as an attribute rather than
+// as a span error.
+func (r *ConfigMapSyncReconciler) traceCall(ctx context.Context, name string, key types.NamespacedName, call func(context.Context) error) error {
+	ctx, span := r.tracer().Start(ctx, name, trace.WithAttributes(
+		attrObjectName.String(key.Name),
+		attrObjectNamespace.String(key.Namespace),
+	))
+	err := call(ctx)
+	if apierrors.IsNotFound(err) {
+		span.SetAttributes(attribute.Bool("k8s.object.not_found", true))
+		endSpan(span, nil)
+		return err
+	}
+	endSpan(span, err)
+	return err
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("Reconcile tracing", func() {
+	ctx := context.Background()
+
+	var (
+		spans      *tracetest.SpanRecorder
+		reconciler *ConfigMapSyncReconciler
+		syncKey    = types.NamespacedName{Name: "app-sync", Namespace: "team-a"}
+	)
+
+	BeforeEach(func() {
+		spans = tracetest.NewSpanRecorder()
+		reconciler = newFakeReconciler(interceptor.Funcs{})
+		reconciler.Tracer = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)).Tracer(TracerName)
+	})
+
+	spanNames := func() []string {
+		var names []string
+		for _, span := range spans.Ended() {
+			names = append(names, span.Name())
+		}
+		return names
+	}
+
+	It("should nest API call spans under a reconcile span carrying the sync", func() {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(spanNames()).To(Equal([]string{
+			"ConfigMap.GetSource",
+			"ConfigMap.GetDestination",
+			"ConfigMap.ApplyDestination",
+			"ConfigMapSync.UpdateStatus",
+			"ConfigMapSync.Reconcile",
+		}))
+
+		ended := spans.Ended()
+		root := ended[len(ended)-1]
+		Expect(root.Attributes()).To(ContainElements(
+			attribute.String("configmapsync.name", "app-sync"),
+			attribute.String("configmapsync.namespace", "team-a"),
+			attribute.String("configmapsync.source", "team-a/app-config"),
+			attribute.StringSlice("configmapsync.destinations", []string{"tenant-1/app-config"}),
+		))
+		for _, child := range ended[:len(ended)-1] {
+			Expect(child.Parent().SpanID()).To(Equal(root.SpanContext().SpanID()))
+		}
+
+		getDestination := ended[1]
+		Expect(getDestination.Attributes()).To(ContainElement(attribute.Bool("k8s.object.not_found", true)))
+		Expect(getDestination.Status().Code).NotTo(Equal(codes.Error))
+	})
+
+	It("should skip the write spans once the destination is up to date", func() {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(spanNames()[5:]).To(Equal([]string{
+			"ConfigMap.GetSource",
+			"ConfigMap.GetDestination",
+			"ConfigMapSync.Reconcile",
+		}))
+	})
+})
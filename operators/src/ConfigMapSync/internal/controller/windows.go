@@ -0,0 +1,314 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// SyncWindowOverrideAnnotation lets changes through closed sync windows
+	// and freezes while it is set to a non-empty value, such as the ID of
+	// the incident that needs the change. Remove it once the change is out.
+	SyncWindowOverrideAnnotation = "configmapsync.apps.kapendra.com/sync-window-override"
+
+	// reasonPendingWindow marks a change held by a sync window or freeze.
+	reasonPendingWindow = "PendingWindow"
+
+	// maxWindowLookahead bounds the search for the next time a change may
+	// be written; windows that stay closed for longer are reported without
+	// a time.
+	maxWindowLookahead = 366 * 24 * time.Hour
+	maxWindowSteps     = 10000
+)
+
+// syncWindow is a parsed appsv2.SyncWindow.
+type syncWindow struct {
+	kind     appsv2.SyncWindowKind
+	spec     string
+	schedule cron.Schedule
+	duration time.Duration
+}
+
+// parseSyncWindows parses the windows of a ConfigMapSync, reading each
+// schedule in its time zone.
+func parseSyncWindows(windows []appsv2.SyncWindow) ([]syncWindow, error) {
+	parsed := make([]syncWindow, 0, len(windows))
+	for i, window := range windows {
+		timeZone := window.TimeZone
+		if timeZone == "" {
+			timeZone = "UTC"
+		}
+		schedule, err := cron.ParseStandard("CRON_TZ=" + timeZone + " " + window.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("syncWindows[%d]: invalid schedule %q in time zone %s: %w", i, window.Schedule, timeZone, err)
+		}
+		if window.Duration.Duration <= 0 {
+			return nil, fmt.Errorf("syncWindows[%d]: duration must be positive", i)
+		}
+		parsed = append(parsed, syncWindow{
+			kind:     window.Kind,
+			spec:     window.Schedule,
+			schedule: schedule,
+			duration: window.Duration.Duration,
+		})
+	}
+	return parsed, nil
+}
+
+// openUntil reports whether the window is open at t and, if it is, when the
+// last occurrence covering t closes.
+func (w syncWindow) openUntil(t time.Time) (time.Time, bool) {
+	start := w.schedule.Next(t.Add(-w.duration))
+	if start.IsZero() || start.After(t) {
+		return time.Time{}, false
+	}
+	for next := w.schedule.Next(start); !next.IsZero() && !next.After(t); next = w.schedule.Next(next) {
+		start = next
+	}
+	return start.Add(w.duration), true
+}
+
+// freezeActive reports whether the freeze is in effect at t.
+func freezeActive(freeze *appsv2.SyncFreeze, t time.Time) bool {
+	return (freeze.Spec.Start == nil || !t.Before(freeze.Spec.Start.Time)) &&
+		(freeze.Spec.End == nil || t.Before(freeze.Spec.End.Time))
+}
+
+// freezeSelects reports whether the freeze applies to configMapSync. An
+// invalid selector freezes every ConfigMapSync rather than none.
+func freezeSelects(freeze *appsv2.SyncFreeze, configMapSync *appsv2.ConfigMapSync) bool {
+	if freeze.Spec.Selector == nil {
+		return true
+	}
+	selector, err := metav1.LabelSelectorAsSelector(freeze.Spec.Selector)
+	return err != nil || selector.Matches(labels.Set(configMapSync.Labels))
+}
+
+// closedAt returns what holds changes back at t, if anything, and when it
+// stops doing so. The time is zero when that is not known.
+func closedAt(t time.Time, windows []syncWindow, freezes []appsv2.SyncFreeze) (string, time.Time) {
+	for i := range freezes {
+		freeze := &freezes[i]
+		if !freezeActive(freeze, t) {
+			continue
+		}
+		holder := "SyncFreeze " + freeze.Name
+		if freeze.Spec.Reason != "" {
+			holder += " (" + freeze.Spec.Reason + ")"
+		}
+		if freeze.Spec.End == nil {
+			return holder, time.Time{}
+		}
+		return holder, freeze.Spec.End.Time
+	}
+
+	for _, window := range windows {
+		if window.kind != appsv2.SyncWindowDeny {
+			continue
+		}
+		if end, open := window.openUntil(t); open {
+			return fmt.Sprintf("deny window %q", window.spec), end
+		}
+	}
+
+	// With allow windows, changes wait for the next one to open
+	allowWindows := false
+	var opens time.Time
+	for _, window := range windows {
+		if window.kind != appsv2.SyncWindowAllow {
+			continue
+		}
+		allowWindows = true
+		if _, open := window.openUntil(t); open {
+			return "", time.Time{}
+		}
+		if next := window.schedule.Next(t); !next.IsZero() && (opens.IsZero() || next.Before(opens)) {
+			opens = next
+		}
+	}
+	if allowWindows {
+		return "the allow windows", opens
+	}
+	return "", time.Time{}
+}
+
+// nextSyncTime returns the earliest time from now on at which the windows
+// and freezes let a change through, and what holds it back until then.
+// known is false when there is no such time within the lookahead, for
+// example during a freeze without an end.
+func nextSyncTime(now time.Time, windows []syncWindow, freezes []appsv2.SyncFreeze) (next time.Time, holder string, known bool) {
+	next = now
+	for range maxWindowSteps {
+		blocker, until := closedAt(next, windows, freezes)
+		if blocker == "" {
+			return next, holder, true
+		}
+		if holder == "" {
+			holder = blocker
+		}
+		if until.IsZero() || until.Sub(now) > maxWindowLookahead {
+			break
+		}
+		next = until
+	}
+	return time.Time{}, holder, false
+}
+
+// freezesFor lists the SyncFreezes that apply to configMapSync.
+func (r *ConfigMapSyncReconciler) freezesFor(ctx context.Context, configMapSync *appsv2.ConfigMapSync) ([]appsv2.SyncFreeze, error) {
+	if !r.SyncFreezes {
+		return nil, nil
+	}
+	list := &appsv2.SyncFreezeList{}
+	if err := r.List(ctx, list); err != nil {
+		return nil, fmt.Errorf("listing SyncFreezes: %w", err)
+	}
+	var freezes []appsv2.SyncFreeze
+	for i := range list.Items {
+		if freezeSelects(&list.Items[i], configMapSync) {
+			freezes = append(freezes, list.Items[i])
+		}
+	}
+	return freezes, nil
+}
+
+// pendingWrites lists the destinations that a sync would write or clean up.
+func (r *ConfigMapSyncReconciler) pendingWrites(ctx context.Context, configMapSync *appsv2.ConfigMapSync, sourceConfigMap *corev1.ConfigMap, desiredConfigMaps []*corev1.ConfigMap) ([]string, error) {
+	var pending []string
+	for _, desired := range desiredConfigMaps {
+		key := client.ObjectKeyFromObject(desired)
+		existing := &corev1.ConfigMap{}
+		err := r.traceCall(ctx, "ConfigMap.GetDestination", key, func(ctx context.Context) error {
+			return r.Get(ctx, key, existing)
+		})
+		if client.IgnoreNotFound(err) != nil {
+			return nil, err
+		}
+		if apierrors.IsNotFound(err) || !destinationUpToDate(existing, desired, sourceConfigMap) {
+			pending = append(pending, key.String())
+		}
+	}
+	desiredKeys := destinationKeys(configMapSync)
+	for _, object := range configMapSync.Status.ManagedObjects {
+		key := types.NamespacedName{Namespace: object.Namespace, Name: object.Name}
+		if !slices.Contains(desiredKeys, key) {
+			pending = append(pending, key.String())
+		}
+	}
+	return pending, nil
+}
+
+// holdOutsideWindow holds the changes a sync would make while its sync
+// windows or a SyncFreeze do not allow them, and requeues the sync for the
+// time they next do. held reports whether the reconcile ends here.
+func (r *ConfigMapSyncReconciler) holdOutsideWindow(ctx context.Context, configMapSync *appsv2.ConfigMapSync, originalStatus *appsv2.ConfigMapSyncStatus, sourceConfigMap *corev1.ConfigMap, desiredConfigMaps []*corev1.ConfigMap) (ctrl.Result, bool, error) {
+	logger := log.FromContext(ctx)
+	release := func() {
+		if configMapSync.Status.Pending != nil && configMapSync.Status.Pending.Reason == reasonPendingWindow {
+			configMapSync.Status.Pending = nil
+		}
+	}
+
+	windows, err := parseSyncWindows(configMapSync.Spec.SyncWindows)
+	if err != nil {
+		result, err := r.blockSync(ctx, configMapSync, originalStatus, "InvalidSyncWindow", err.Error())
+		return result, true, err
+	}
+	freezes, err := r.freezesFor(ctx, configMapSync)
+	if err != nil {
+		return ctrl.Result{}, true, err
+	}
+	if len(windows) == 0 && len(freezes) == 0 {
+		release()
+		return ctrl.Result{}, false, nil
+	}
+
+	now := r.clock()
+	next, holder, known := nextSyncTime(now, windows, freezes)
+	if known && !next.After(now) {
+		release()
+		return ctrl.Result{}, false, nil
+	}
+
+	// Nothing needs writing, so there is nothing to hold
+	pending, err := r.pendingWrites(ctx, configMapSync, sourceConfigMap, desiredConfigMaps)
+	if err != nil {
+		return ctrl.Result{}, true, err
+	}
+	if len(pending) == 0 {
+		release()
+		return ctrl.Result{}, false, nil
+	}
+
+	if override := configMapSync.Annotations[SyncWindowOverrideAnnotation]; override != "" {
+		logger.Info("Sync window overridden, writing held changes", "heldBy", holder, "override", override)
+		r.recordEvent(configMapSync, nil, corev1.EventTypeWarning, EventReasonWindowOverridden,
+			fmt.Sprintf("Writing changes held by %s, overridden with %s=%s", holder, SyncWindowOverrideAnnotation, override))
+		release()
+		return ctrl.Result{}, false, nil
+	}
+
+	change := &appsv2.PendingChange{
+		Reason:                reasonPendingWindow,
+		Message:               "Change held by " + holder,
+		SourceResourceVersion: sourceConfigMap.ResourceVersion,
+	}
+	if known {
+		change.Message += " until " + next.UTC().Format(time.RFC3339)
+		change.NotBefore = &metav1.Time{Time: next}
+	}
+	logger.Info("Holding change outside the sync windows", "heldBy", holder, "destinations", pending, "notBefore", change.NotBefore)
+	if !equality.Semantic.DeepEqual(change, configMapSync.Status.Pending) {
+		r.recordEvent(configMapSync, nil, corev1.EventTypeNormal, EventReasonPendingWindow, change.Message)
+	}
+	result, err := r.holdChange(ctx, configMapSync, originalStatus, change, now)
+	return result, true, err
+}
+
+// syncsForFreeze enqueues every ConfigMapSync when a SyncFreeze changes.
+// Freezes change rarely, and a changed selector can release syncs that
+// the new one no longer matches.
+func (r *ConfigMapSyncReconciler) syncsForFreeze(ctx context.Context, _ client.Object) []reconcile.Request {
+	syncs := &appsv2.ConfigMapSyncList{}
+	if err := r.List(ctx, syncs); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list ConfigMapSyncs of changed SyncFreeze")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(syncs.Items))
+	for i := range syncs.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&syncs.Items[i])})
+	}
+	return requests
+}
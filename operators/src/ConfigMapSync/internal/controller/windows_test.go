@@ -0,0 +1,248 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+var _ = Describe("Sync windows", func() {
+	// Saturday 17 October 2026, 12:00 UTC
+	saturday := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)
+	// Monday 19 October 2026, 09:00 in Berlin (CEST)
+	mondayMorning := time.Date(2026, time.October, 19, 7, 0, 0, 0, time.UTC)
+
+	businessHours := appsv2.SyncWindow{
+		Kind:     appsv2.SyncWindowAllow,
+		Schedule: "0 9 * * 1-5",
+		Duration: metav1.Duration{Duration: 8 * time.Hour},
+		TimeZone: "Europe/Berlin",
+	}
+
+	parse := func(windows ...appsv2.SyncWindow) []syncWindow {
+		parsed, err := parseSyncWindows(windows)
+		Expect(err).NotTo(HaveOccurred())
+		return parsed
+	}
+
+	It("should reject invalid schedules, time zones and durations", func() {
+		for _, window := range []appsv2.SyncWindow{
+			{Kind: appsv2.SyncWindowAllow, Schedule: "0 25 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+			{Kind: appsv2.SyncWindowAllow, Schedule: "0 9 * * *", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: "Mars/Olympus"},
+			{Kind: appsv2.SyncWindowAllow, Schedule: "0 9 * * *"},
+		} {
+			_, err := parseSyncWindows([]appsv2.SyncWindow{window})
+			Expect(err).To(MatchError(ContainSubstring("syncWindows[0]")))
+		}
+	})
+
+	It("should wait for the next allow window in its time zone", func() {
+		next, holder, known := nextSyncTime(saturday, parse(businessHours), nil)
+		Expect(known).To(BeTrue())
+		Expect(next).To(BeTemporally("==", mondayMorning))
+		Expect(holder).To(Equal("the allow windows"))
+
+		during := mondayMorning.Add(3 * time.Hour)
+		next, _, known = nextSyncTime(during, parse(businessHours), nil)
+		Expect(known).To(BeTrue())
+		Expect(next).To(BeTemporally("==", during))
+	})
+
+	It("should let deny windows take precedence over allow windows", func() {
+		// No changes on Monday mornings before 11:00 Berlin time
+		deny := appsv2.SyncWindow{
+			Kind:     appsv2.SyncWindowDeny,
+			Schedule: "0 9 * * 1",
+			Duration: metav1.Duration{Duration: 2 * time.Hour},
+			TimeZone: "Europe/Berlin",
+		}
+		next, holder, known := nextSyncTime(saturday, parse(businessHours, deny), nil)
+		Expect(known).To(BeTrue())
+		Expect(next).To(BeTemporally("==", mondayMorning.Add(2*time.Hour)))
+		Expect(holder).To(Equal("the allow windows"))
+
+		next, holder, _ = nextSyncTime(mondayMorning.Add(time.Hour), parse(businessHours, deny), nil)
+		Expect(next).To(BeTemporally("==", mondayMorning.Add(2*time.Hour)))
+		Expect(holder).To(Equal(`deny window "0 9 * * 1"`))
+	})
+
+	It("should hold changes until a freeze ends, or indefinitely without an end", func() {
+		freeze := appsv2.SyncFreeze{
+			ObjectMeta: metav1.ObjectMeta{Name: "year-end"},
+			Spec: appsv2.SyncFreezeSpec{
+				Start:  &metav1.Time{Time: saturday.Add(-time.Hour)},
+				End:    &metav1.Time{Time: mondayMorning.Add(time.Hour)},
+				Reason: "release",
+			},
+		}
+		next, holder, known := nextSyncTime(saturday, parse(businessHours), []appsv2.SyncFreeze{freeze})
+		Expect(known).To(BeTrue())
+		Expect(next).To(BeTemporally("==", mondayMorning.Add(time.Hour)))
+		Expect(holder).To(Equal("SyncFreeze year-end (release)"))
+
+		freeze.Spec.End = nil
+		_, _, known = nextSyncTime(saturday, nil, []appsv2.SyncFreeze{freeze})
+		Expect(known).To(BeFalse())
+
+		// A freeze that has not started yet does not hold anything
+		freeze.Spec.Start = &metav1.Time{Time: mondayMorning}
+		next, _, known = nextSyncTime(saturday, nil, []appsv2.SyncFreeze{freeze})
+		Expect(known).To(BeTrue())
+		Expect(next).To(BeTemporally("==", saturday))
+	})
+
+	It("should only apply freezes to the ConfigMapSyncs they select", func() {
+		production := &appsv2.ConfigMapSync{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"environment": "production"}}}
+		staging := &appsv2.ConfigMapSync{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"environment": "staging"}}}
+		freeze := &appsv2.SyncFreeze{Spec: appsv2.SyncFreezeSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"environment": "production"}},
+		}}
+		Expect(freezeSelects(freeze, production)).To(BeTrue())
+		Expect(freezeSelects(freeze, staging)).To(BeFalse())
+
+		freeze.Spec.Selector = nil
+		Expect(freezeSelects(freeze, staging)).To(BeTrue())
+	})
+
+	Context("when reconciling", func() {
+		ctx := context.Background()
+		syncKey := types.NamespacedName{Name: "app-sync", Namespace: "team-a"}
+		sourceKey := types.NamespacedName{Name: "app-config", Namespace: "team-a"}
+		destinationKey := types.NamespacedName{Name: "app-config", Namespace: "tenant-1"}
+
+		var (
+			reconciler *ConfigMapSyncReconciler
+			now        time.Time
+		)
+
+		BeforeEach(func() {
+			configMapSync := newTestSync(func(spec *appsv2.ConfigMapSyncSpec) {
+				spec.SyncWindows = []appsv2.SyncWindow{businessHours}
+			})
+			configMapSync.Labels = map[string]string{"environment": "production"}
+			reconciler = newFakeReconciler(interceptor.Funcs{}, configMapSync, newTestSource(map[string]string{"key": "v1"}))
+			reconciler.SyncFreezes = true
+			reconciler.now = func() time.Time { return now }
+
+			// First sync during business hours
+			now = mondayMorning.Add(time.Hour)
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		changeSource := func() {
+			source := &corev1.ConfigMap{}
+			Expect(reconciler.Get(ctx, sourceKey, source)).To(Succeed())
+			source.Data["key"] = "v2"
+			Expect(reconciler.Update(ctx, source)).To(Succeed())
+		}
+
+		destinationValue := func() string {
+			destination := &corev1.ConfigMap{}
+			Expect(reconciler.Get(ctx, destinationKey, destination)).To(Succeed())
+			return destination.Data["key"]
+		}
+
+		reconcile := func() (ctrl.Result, *appsv2.ConfigMapSync) {
+			result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: syncKey})
+			Expect(err).NotTo(HaveOccurred())
+			configMapSync := &appsv2.ConfigMapSync{}
+			Expect(reconciler.Get(ctx, syncKey, configMapSync)).To(Succeed())
+			return result, configMapSync
+		}
+
+		It("should sync steady state outside the windows without holding anything", func() {
+			now = saturday.Add(7 * 24 * time.Hour)
+			result, configMapSync := reconcile()
+			Expect(result.RequeueAfter).To(BeZero())
+			Expect(configMapSync.Status.Pending).To(BeNil())
+			Expect(meta.IsStatusConditionTrue(configMapSync.Status.Conditions, TypeReady)).To(BeTrue())
+		})
+
+		It("should hold a change until the next window and requeue exactly then", func() {
+			// Friday evening, the window reopens on Monday morning
+			now = mondayMorning.Add(4*24*time.Hour + 10*time.Hour)
+			changeSource()
+
+			result, configMapSync := reconcile()
+			// Berlin is back on CET by then, so the window opens at 08:00 UTC
+			nextMonday := time.Date(2026, time.October, 26, 8, 0, 0, 0, time.UTC)
+			Expect(result.RequeueAfter).To(Equal(nextMonday.Sub(now)))
+			Expect(destinationValue()).To(Equal("v1"))
+			Expect(configMapSync.Status.Pending).NotTo(BeNil())
+			Expect(configMapSync.Status.Pending.Reason).To(Equal("PendingWindow"))
+			Expect(configMapSync.Status.Pending.NotBefore.Time).To(BeTemporally("==", nextMonday))
+			Expect(meta.FindStatusCondition(configMapSync.Status.Conditions, TypeReconciling)).To(HaveField("Reason", "PendingWindow"))
+
+			now = nextMonday
+			_, configMapSync = reconcile()
+			Expect(destinationValue()).To(Equal("v2"))
+			Expect(configMapSync.Status.Pending).To(BeNil())
+		})
+
+		It("should let an emergency change through with the override annotation", func() {
+			now = saturday.Add(7 * 24 * time.Hour)
+			changeSource()
+			reconcile()
+			Expect(destinationValue()).To(Equal("v1"))
+
+			configMapSync := &appsv2.ConfigMapSync{}
+			Expect(reconciler.Get(ctx, syncKey, configMapSync)).To(Succeed())
+			configMapSync.Annotations = map[string]string{SyncWindowOverrideAnnotation: "INC-1234"}
+			Expect(reconciler.Update(ctx, configMapSync)).To(Succeed())
+
+			_, configMapSync = reconcile()
+			Expect(destinationValue()).To(Equal("v2"))
+			Expect(configMapSync.Status.Pending).To(BeNil())
+		})
+
+		It("should hold changes during a matching SyncFreeze", func() {
+			now = mondayMorning.Add(2 * time.Hour)
+			Expect(reconciler.Create(ctx, &appsv2.SyncFreeze{
+				ObjectMeta: metav1.ObjectMeta{Name: "incident"},
+				Spec: appsv2.SyncFreezeSpec{
+					Reason:   "INC-1234",
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"environment": "production"}},
+				},
+			})).To(Succeed())
+			changeSource()
+
+			result, configMapSync := reconcile()
+			Expect(result.RequeueAfter).To(BeZero())
+			Expect(destinationValue()).To(Equal("v1"))
+			Expect(configMapSync.Status.Pending.NotBefore).To(BeNil())
+			Expect(configMapSync.Status.Pending.Message).To(ContainSubstring("SyncFreeze incident (INC-1234)"))
+
+			Expect(reconciler.Delete(ctx, &appsv2.SyncFreeze{ObjectMeta: metav1.ObjectMeta{Name: "incident"}})).To(Succeed())
+			reconcile()
+			Expect(destinationValue()).To(Equal("v2"))
+		})
+	})
+})
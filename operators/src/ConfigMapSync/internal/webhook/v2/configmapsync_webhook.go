@@ -0,0 +1,30 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+)
+
+// SetupConfigMapSyncWebhookWithManager registers the webhook for ConfigMapSync in the manager.
+// v2 is the conversion hub, so this serves /convert for every spoke version.
+func SetupConfigMapSyncWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&appsv2.ConfigMapSync{}).
+		Complete()
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	appsv1 "operators/src/ConfigMapSync/api/v1"
+	appsv2 "operators/src/ConfigMapSync/api/v2"
+	// TODO (user): Add any additional imports if needed
+)
+
+var _ = Describe("ConfigMapSync Webhook", func() {
+	var (
+		v1Object *appsv1.ConfigMapSync
+		v2Object *appsv2.ConfigMapSync
+	)
+
+	BeforeEach(func() {
+		v1Object = &appsv1.ConfigMapSync{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "app-sync",
+				Namespace:   "default",
+				Annotations: map[string]string{"team": "platform"},
+			},
+			Spec: appsv1.ConfigMapSyncSpec{
+				SourceNamespace:       "default",
+				DestinationNamespace:  "tenant-1",
+				ConfigMapName:         "app-config",
+				SensitiveDataScanMode: appsv1.SensitiveDataScanWarn,
+				SignatureVerification: &appsv1.SignatureVerification{
+					PublicKeysRef: appsv1.KeyReference{Kind: "Secret", Name: "signing-keys"},
+				},
+			},
+			Status: appsv1.ConfigMapSyncStatus{
+				LastSyncTime:               "2025-01-23T10:30:45Z",
+				SyncStatus:                 "Success",
+				Message:                    "ConfigMap synced successfully",
+				SourceExists:               true,
+				DestinationExists:          true,
+				ObservedGeneration:         3,
+				SyncedSourceHash:           "abc",
+				SourceResourceVersion:      "10",
+				DestinationResourceVersion: "42",
+				KeyCount:                   2,
+				ByteSize:                   64,
+				LastChange:                 &appsv1.SyncChangeSummary{Added: []string{"key"}},
+				Conditions: []metav1.Condition{{
+					Type:               "Ready",
+					Status:             metav1.ConditionTrue,
+					Reason:             "AllComponentsReady",
+					LastTransitionTime: metav1.NewTime(time.Date(2025, 1, 23, 10, 30, 45, 0, time.UTC)),
+				}},
+			},
+		}
+
+		v2Object = &appsv2.ConfigMapSync{
+			ObjectMeta: metav1.ObjectMeta{Name: "fan-out", Namespace: "default"},
+			Spec: appsv2.ConfigMapSyncSpec{
+				Source: appsv2.SourceReference{Namespace: "default", Name: "app-config"},
+				Destinations: []appsv2.DestinationReference{
+					{Namespace: "tenant-1"},
+					{Namespace: "tenant-2", Name: "renamed"},
+				},
+				Decryption: &appsv2.Decryption{Provider: "sops", SecretRef: appsv2.SecretReference{Name: "age-keys"}},
+			},
+		}
+	})
+
+	Context("When converting ConfigMapSync between versions", func() {
+		It("Should round-trip a v1 object through the v2 hub without loss", func() {
+			hub := &appsv2.ConfigMapSync{}
+			Expect(v1Object.ConvertTo(hub)).To(Succeed())
+			Expect(hub.Spec.Source).To(Equal(appsv2.SourceReference{Namespace: "default", Name: "app-config"}))
+			Expect(hub.Spec.Destinations).To(Equal([]appsv2.DestinationReference{{Namespace: "tenant-1"}}))
+			Expect(hub.Status.LastSyncTime.UTC()).To(Equal(time.Date(2025, 1, 23, 10, 30, 45, 0, time.UTC)))
+			Expect(hub.Status.Destinations).To(HaveLen(1))
+			Expect(hub.Annotations).NotTo(HaveKey(appsv1.V2SpecAnnotation))
+
+			converted := &appsv1.ConfigMapSync{}
+			Expect(converted.ConvertFrom(hub)).To(Succeed())
+			Expect(converted).To(Equal(v1Object))
+		})
+
+		It("Should preserve v2-only fields when read and written back through v1", func() {
+			spoke := &appsv1.ConfigMapSync{}
+			Expect(spoke.ConvertFrom(v2Object)).To(Succeed())
+			Expect(spoke.Spec.DestinationNamespace).To(Equal("tenant-1"))
+			Expect(spoke.Annotations).To(HaveKey(appsv1.V2SpecAnnotation))
+
+			hub := &appsv2.ConfigMapSync{}
+			Expect(spoke.ConvertTo(hub)).To(Succeed())
+			Expect(hub.Spec).To(Equal(v2Object.Spec))
+			Expect(hub.Annotations).To(BeEmpty())
+		})
+
+		It("Should apply edits made through v1 on top of the preserved fields", func() {
+			spoke := &appsv1.ConfigMapSync{}
+			Expect(spoke.ConvertFrom(v2Object)).To(Succeed())
+			spoke.Spec.DestinationNamespace = "tenant-3"
+			spoke.Spec.Decryption = nil
+
+			hub := &appsv2.ConfigMapSync{}
+			Expect(spoke.ConvertTo(hub)).To(Succeed())
+			Expect(hub.Spec.Destinations).To(Equal([]appsv2.DestinationReference{
+				{Namespace: "tenant-3"},
+				{Namespace: "tenant-2", Name: "renamed"},
+			}))
+			Expect(hub.Spec.Decryption).To(BeNil())
+		})
+
+		It("Should not annotate v2 objects that v1 can represent", func() {
+			v2Object.Spec.Destinations = v2Object.Spec.Destinations[:1]
+			spoke := &appsv1.ConfigMapSync{}
+			Expect(spoke.ConvertFrom(v2Object)).To(Succeed())
+			Expect(spoke.Annotations).NotTo(HaveKey(appsv1.V2SpecAnnotation))
+		})
+	})
+
+	Context("When serving both versions from the API server", func() {
+		AfterEach(func() {
+			for _, name := range []string{v1Object.Name, v2Object.Name} {
+				_ = k8sClient.Delete(ctx, &appsv2.ConfigMapSync{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}})
+			}
+		})
+
+		It("Should serve v1 objects as v2 and back", func() {
+			v1Object.Status = appsv1.ConfigMapSyncStatus{}
+			Expect(k8sClient.Create(ctx, v1Object)).To(Succeed())
+			key := types.NamespacedName{Name: v1Object.Name, Namespace: v1Object.Namespace}
+
+			stored := &appsv2.ConfigMapSync{}
+			Expect(k8sClient.Get(ctx, key, stored)).To(Succeed())
+			Expect(stored.Spec.Source.Name).To(Equal("app-config"))
+			Expect(stored.Spec.Destinations).To(Equal([]appsv2.DestinationReference{{Namespace: "tenant-1"}}))
+
+			served := &appsv1.ConfigMapSync{}
+			Expect(k8sClient.Get(ctx, key, served)).To(Succeed())
+			Expect(served.Spec).To(Equal(v1Object.Spec))
+			Expect(served.Annotations).NotTo(HaveKey(appsv1.V2SpecAnnotation))
+		})
+
+		It("Should keep additional destinations when a v1 client updates a v2 object", func() {
+			Expect(k8sClient.Create(ctx, v2Object)).To(Succeed())
+			key := types.NamespacedName{Name: v2Object.Name, Namespace: v2Object.Namespace}
+
+			served := &appsv1.ConfigMapSync{}
+			Expect(k8sClient.Get(ctx, key, served)).To(Succeed())
+			served.Spec.SensitiveDataScanMode = appsv1.SensitiveDataScanBlock
+			Expect(k8sClient.Update(ctx, served)).To(Succeed())
+
+			stored := &appsv2.ConfigMapSync{}
+			Expect(k8sClient.Get(ctx, key, stored)).To(Succeed())
+			Expect(stored.Spec.Destinations).To(Equal(v2Object.Spec.Destinations))
+			Expect(stored.Spec.Decryption).To(Equal(v2Object.Spec.Decryption))
+			Expect(stored.Spec.SensitiveDataScanMode).To(Equal(appsv2.SensitiveDataScanBlock))
+			Expect(stored.Annotations).NotTo(HaveKey(appsv1.V2SpecAnnotation))
+		})
+	})
+})